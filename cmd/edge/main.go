@@ -9,11 +9,13 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	serial "github.com/jscottransom/fringe/internal/proto"
 	"github.com/jscottransom/fringe/internal/swim"
+	"github.com/jscottransom/fringe/internal/sync"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -24,23 +26,11 @@ var (
 		Name: "fringe_cluster_size",
 		Help: "Current number of nodes in the cluster",
 	})
-
-	pingLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
-		Name:    "fringe_ping_latency_seconds",
-		Help:    "Ping latency in seconds",
-		Buckets: prometheus.DefBuckets,
-	})
-
-	messageCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "fringe_messages_total",
-		Help: "Total number of messages by type",
-	}, []string{"type"})
 )
 
 func init() {
 	prometheus.MustRegister(clusterSize)
-	prometheus.MustRegister(pingLatency)
-	prometheus.MustRegister(messageCounter)
+	prometheus.MustRegister(swim.Collectors()...)
 }
 
 // Starts a Fringe node in the SWIM cluster with configurable bootstrap and join behavior
@@ -49,8 +39,26 @@ func main() {
 	knownNode := flag.String("node", "", "Address of an existing node in the cluster")
 	port := flag.Int("port", 0, "Port to listen on (0 for random)")
 	metricsPort := flag.Int("metrics-port", 9090, "Port for metrics endpoint")
+	probeInterval := flag.Duration("probe-interval", swim.DefaultConfig().ProbeInterval, "SWIM protocol period")
+	probeTimeout := flag.Duration("probe-timeout", swim.DefaultConfig().ProbeTimeout, "Timeout for a direct probe")
+	indirectChecks := flag.Int("indirect-checks", swim.DefaultConfig().IndirectChecks, "Number of peers asked to probe indirectly")
+	localHealth := flag.Bool("local-health", true, "Stretch probe timeouts when this node is unhealthy (Lifeguard)")
+	dynamicSuspicion := flag.Bool("dynamic-suspicion", true, "Shrink suspicion timeouts as confirmations arrive (Lifeguard)")
+	buddySystem := flag.Bool("buddy-system", true, "Tell suspected peers about their suspicion when probing them (Lifeguard)")
+	transportKind := flag.String("transport", "quic", "Transport for SWIM messages: quic or udp")
+	metaFlag := flag.String("meta", "", "Comma-separated key=value metadata gossiped with this node, e.g. region=eu-west,role=edge")
+	syncInterval := flag.Duration("sync-interval", 10*time.Second, "Interval between anti-entropy syncs with a random peer")
+	tombstoneTTL := flag.Duration("tombstone-ttl", sync.DefaultTombstoneTTL, "How long deletes are remembered if not every member acknowledges them")
 	flag.Parse()
 
+	config := swim.DefaultConfig()
+	config.ProbeInterval = *probeInterval
+	config.ProbeTimeout = *probeTimeout
+	config.IndirectChecks = *indirectChecks
+	config.LocalHealth = *localHealth
+	config.DynamicSuspicion = *dynamicSuspicion
+	config.BuddySystem = *buddySystem
+
 	udp, err := net.ListenUDP("udp", &net.UDPAddr{Port: *port})
 	if err != nil {
 		log.Fatalf("failed to listen UDP: %v", err)
@@ -62,17 +70,52 @@ func main() {
 
 	log.Printf("Starting Fringe node: %s", nodeID)
 
-	node, err := initNode(nodeID, nodeAddr, *bootstrap)
+	node, err := initNode(nodeID, nodeAddr, *bootstrap, config)
 	if err != nil {
 		log.Fatalf("failed to initialize node: %v", err)
 	}
 
+	if *metaFlag != "" {
+		meta, err := parseMeta(*metaFlag)
+		if err != nil {
+			log.Fatalf("invalid metadata: %v", err)
+		}
+		if err := node.SetMeta(meta); err != nil {
+			log.Fatalf("failed to set metadata: %v", err)
+		}
+	}
+
+	switch *transportKind {
+	case "quic":
+		tr, err := swim.NewQUICTransport(udp)
+		if err != nil {
+			log.Fatalf("failed to create QUIC transport: %v", err)
+		}
+		node.Transport = tr
+	case "udp":
+		node.Transport = swim.NewUDPTransport(udp)
+	default:
+		log.Fatalf("unknown transport %q", *transportKind)
+	}
+
 	go startMetricsServer(*metricsPort)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go node.StartGossip(ctx)
+	go func() {
+		if err := node.Serve(ctx); err != nil {
+			log.Fatalf("failed to serve: %v", err)
+		}
+	}()
+
+	if err := node.StartGossip(ctx); err != nil {
+		log.Fatalf("failed to start gossip: %v", err)
+	}
+
+	tree := sync.NewMerkleTree(nodeID, 4)
+	tree.TombstoneTTL = *tombstoneTTL
+	sync.NewService(node, tree, *syncInterval).Start(ctx)
 
 	if !*bootstrap && *knownNode != "" {
 		if err := node.JoinCluster(*knownNode); err != nil {
@@ -85,11 +128,30 @@ func main() {
 
 	<-sigChan
 	log.Println("Shutting down...")
+
+	leaveCtx, leaveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := node.Leave(leaveCtx); err != nil {
+		log.Printf("Failed to leave cluster gracefully: %v", err)
+	}
+	leaveCancel()
 	cancel()
 }
 
+// Parses comma-separated key=value pairs into a metadata map
+func parseMeta(s string) (map[string]string, error) {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		meta[key] = value
+	}
+	return meta, nil
+}
+
 // Creates and initializes a new Fringe node with member table and piggyback queue
-func initNode(nodeID, nodeAddr string, bootstrap bool) (*swim.Node, error) {
+func initNode(nodeID, nodeAddr string, bootstrap bool, config swim.Config) (*swim.Node, error) {
 	memberTable := &swim.NodeTable{
 		Members: make(map[string]*swim.Peer),
 	}
@@ -97,6 +159,7 @@ func initNode(nodeID, nodeAddr string, bootstrap bool) (*swim.Node, error) {
 	queue := &swim.PiggyBackQueue{
 		Entries:  make([]*swim.Entry, 0),
 		Capacity: 100,
+		NumNodes: memberTable.GetClusterSize,
 	}
 
 	selfPeer := &swim.Peer{
@@ -129,6 +192,7 @@ func initNode(nodeID, nodeAddr string, bootstrap bool) (*swim.Node, error) {
 		Queue:       queue,
 		Addr:        nodeAddr,
 		Bootstrap:   bootstrap,
+		Config:      config,
 	}
 
 	return node, nil
@@ -0,0 +1,238 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock is the source of time and timers for the SWIM protocol, so tests can swap in virtual time
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending callback created by Clock.AfterFunc
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Real is the Clock backed by the time package
+type Real struct{}
+
+// Returns the current wall clock time
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Returns the wall clock time elapsed since t
+func (Real) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+// Calls f in its own goroutine after d has elapsed
+func (Real) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// Returns a context that times out once d has passed on the clock. On the real clock this is
+// context.WithTimeout; on any other clock the deadline is in that clock's time, so work done under
+// virtual time never times out because wall clock time has passed. The parent's deadline is not
+// inherited there, since it may be on a different clock, but its cancellation is.
+func WithTimeout(parent context.Context, c Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := c.(Real); ok {
+		return context.WithTimeout(parent, d)
+	}
+	ctx, cancel := context.WithCancel(parent)
+	timeout := &timeoutContext{Context: ctx, deadline: c.Now().Add(d)}
+	timer := c.AfterFunc(d, func() {
+		timeout.expired.Store(true)
+		cancel()
+	})
+	return timeout, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+// timeoutContext is a context whose deadline is on a clock other than the wall clock
+type timeoutContext struct {
+	context.Context
+	deadline time.Time
+	expired  atomic.Bool
+}
+
+// Returns the deadline on the context's clock
+func (c *timeoutContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// Returns context.DeadlineExceeded once the clock has passed the deadline
+func (c *timeoutContext) Err() error {
+	err := c.Context.Err()
+	if err != nil && c.expired.Load() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// Fake is a manually advanced virtual clock. Timer callbacks run synchronously on the
+// goroutine calling Advance, in deadline order, so timer-driven code becomes deterministic.
+type Fake struct {
+	now    time.Time
+	timers []*fakeTimer
+	seq    uint64
+	mu     sync.Mutex
+}
+
+// fakeTimer is a callback scheduled on a Fake clock
+type fakeTimer struct {
+	clock  *Fake
+	when   time.Time
+	seq    uint64
+	fn     func()
+	active bool
+}
+
+// Creates a virtual clock starting at the given time
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Returns the current virtual time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Returns the virtual time elapsed since t
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Schedules f to run once virtual time has advanced by d
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{clock: f, fn: fn}
+	f.schedule(t, d)
+	return t
+}
+
+// Moves virtual time forward by d, running every timer that falls due along the way.
+// Timers scheduled by those callbacks also run if they fall within d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	end := f.now.Add(d)
+	for {
+		t := f.popDue(end)
+		if t == nil {
+			break
+		}
+		if t.when.After(f.now) {
+			f.now = t.when
+		}
+		f.mu.Unlock()
+		t.fn()
+		f.mu.Lock()
+	}
+	if end.After(f.now) {
+		f.now = end
+	}
+	f.mu.Unlock()
+}
+
+// Runs only the earliest timer due within d, moving virtual time to its deadline.
+// Reports false, leaving time unchanged, if no timer is due.
+func (f *Fake) AdvanceOne(d time.Duration) bool {
+	f.mu.Lock()
+	t := f.popDue(f.now.Add(d))
+	if t == nil {
+		f.mu.Unlock()
+		return false
+	}
+	if t.when.After(f.now) {
+		f.now = t.when
+	}
+	f.mu.Unlock()
+
+	t.fn()
+	return true
+}
+
+// Returns the number of timers waiting to fire
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// Adds the timer to fire d from now; the caller must hold the clock lock
+func (f *Fake) schedule(t *fakeTimer, d time.Duration) {
+	f.seq++
+	t.when = f.now.Add(d)
+	t.seq = f.seq
+	t.active = true
+	f.timers = append(f.timers, t)
+}
+
+// Removes and returns the earliest timer due by end, breaking ties in scheduling order;
+// the caller must hold the clock lock
+func (f *Fake) popDue(end time.Time) *fakeTimer {
+	idx := -1
+	for i, t := range f.timers {
+		if t.when.After(end) {
+			continue
+		}
+		if idx < 0 || t.when.Before(f.timers[idx].when) || (t.when.Equal(f.timers[idx].when) && t.seq < f.timers[idx].seq) {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return nil
+	}
+	t := f.timers[idx]
+	f.remove(t)
+	return t
+}
+
+// Removes the timer from the pending list; the caller must hold the clock lock
+func (f *Fake) remove(t *fakeTimer) {
+	for i, pending := range f.timers {
+		if pending == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			break
+		}
+	}
+	t.active = false
+}
+
+// Cancels the timer, reporting whether it was still pending
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	if wasActive {
+		t.clock.remove(t)
+	}
+	return wasActive
+}
+
+// Reschedules the timer to fire d from now, reporting whether it was still pending
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	if wasActive {
+		t.clock.remove(t)
+	}
+	t.clock.schedule(t, d)
+	return wasActive
+}
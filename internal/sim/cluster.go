@@ -0,0 +1,185 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jscottransom/fringe/internal/clock"
+	serial "github.com/jscottransom/fringe/internal/proto"
+	"github.com/jscottransom/fringe/internal/swim"
+)
+
+// Cluster runs many SWIM nodes in one process over a simulated network on a shared virtual clock
+type Cluster struct {
+	Clock   *clock.Fake
+	Network *Network
+	Nodes   []*swim.Node
+
+	cancels []context.CancelFunc
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// Creates a cluster of size nodes with the given configuration; nodes do not know about each
+// other until Join is called. The seed drives packet loss and every node's random choices
+func NewCluster(size int, config swim.Config, seed int64) *Cluster {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Cluster{
+		Clock:   clock.NewFake(time.Unix(0, 0)),
+		Network: NewNetwork(seed),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	c.Network.Clock = c.Clock
+
+	for i := range size {
+		addr := fmt.Sprintf("sim-%d", i)
+		rng := rand.New(rand.NewSource(seed + int64(i)))
+		node := newNode(addr, config, c.Clock, c.Network.NewTransport(addr), rng)
+
+		nodeCtx, nodeCancel := context.WithCancel(ctx)
+		go node.Serve(nodeCtx)
+		if err := node.StartGossip(nodeCtx); err != nil {
+			panic(err)
+		}
+
+		c.Nodes = append(c.Nodes, node)
+		c.cancels = append(c.cancels, nodeCancel)
+	}
+	return c
+}
+
+// Builds a node that starts out knowing only itself
+func newNode(addr string, config swim.Config, clk clock.Clock, tr swim.Transport, rng *rand.Rand) *swim.Node {
+	nodeID := fmt.Sprintf("node-%s", addr)
+
+	memberTable := &swim.NodeTable{
+		Members: make(map[string]*swim.Peer),
+		Clock:   clk,
+	}
+	memberTable.AddPeer(nodeID, &swim.Peer{
+		PeerID:           nodeID,
+		Address:          addr,
+		State:            swim.Alive,
+		Incarnation:      1,
+		SinceStateUpdate: clk.Now(),
+	})
+
+	queue := &swim.PiggyBackQueue{
+		Entries:  make([]*swim.Entry, 0),
+		Capacity: 10,
+		NumNodes: memberTable.GetClusterSize,
+		Clock:    clk,
+	}
+	queue.AddEntry(&swim.Entry{
+		Update: &serial.MembershipUpdate{
+			NodeId:      nodeID,
+			Address:     addr,
+			Incarnation: 1,
+			State:       serial.State_ALIVE,
+		},
+		Expiry:    clk.Now().Add(swim.PeerTTL),
+		SeenPeers: make(map[string]bool),
+	})
+
+	return &swim.Node{
+		NodeId:      nodeID,
+		MemberTable: memberTable,
+		Queue:       queue,
+		Addr:        addr,
+		Config:      config,
+		Transport:   tr,
+		Clock:       clk,
+		Rand:        rng,
+	}
+}
+
+// Joins every node to the cluster through the first one
+func (c *Cluster) Join() error {
+	for _, node := range c.Nodes[1:] {
+		if err := node.JoinCluster(c.Nodes[0].Addr); err != nil {
+			return fmt.Errorf("failed to join %s: %w", node.NodeId, err)
+		}
+	}
+	return nil
+}
+
+// Advances virtual time by d, firing protocol timers one at a time and letting the network
+// settle after each so runs do not depend on goroutine scheduling
+func (c *Cluster) Run(d time.Duration) {
+	c.RunUntil(d, func() bool { return false })
+}
+
+// Advances virtual time until cond holds or d has elapsed, returning the virtual time taken
+func (c *Cluster) RunUntil(d time.Duration, cond func() bool) (time.Duration, bool) {
+	start := c.Clock.Now()
+	end := start.Add(d)
+	for {
+		if cond() {
+			return c.Clock.Since(start), true
+		}
+		if !c.Clock.AdvanceOne(end.Sub(c.Clock.Now())) {
+			break
+		}
+		c.Network.Wait()
+	}
+	c.Clock.Advance(end.Sub(c.Clock.Now()))
+	return d, cond()
+}
+
+// Crashes the node at index i: it stops gossiping and the network drops its traffic
+func (c *Cluster) Kill(i int) {
+	c.Network.Down(c.Nodes[i].Addr)
+	c.cancels[i]()
+}
+
+// Returns the nodes that have not been killed
+func (c *Cluster) Live() []*swim.Node {
+	var live []*swim.Node
+	for i, node := range c.Nodes {
+		if c.isLive(i) {
+			live = append(live, node)
+		}
+	}
+	return live
+}
+
+// Reports whether every live node sees every other live node as Alive
+func (c *Cluster) Converged() bool {
+	live := c.Live()
+	for _, node := range live {
+		for _, other := range live {
+			if peer, ok := node.MemberTable.GetPeer(other.NodeId); !ok || peer.State != swim.Alive {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Reports whether every live node other than the target sees it in the given state
+func (c *Cluster) AllSee(target *swim.Node, state swim.NodeState) bool {
+	for _, node := range c.Live() {
+		if node == target {
+			continue
+		}
+		if peer, ok := node.MemberTable.GetPeer(target.NodeId); !ok || peer.State != state {
+			return false
+		}
+	}
+	return true
+}
+
+// Stops every node in the cluster
+func (c *Cluster) Close() {
+	c.cancel()
+}
+
+// Reports whether the node at index i is still running
+func (c *Cluster) isLive(i int) bool {
+	c.Network.mu.Lock()
+	defer c.Network.mu.Unlock()
+	return !c.Network.down[c.Nodes[i].Addr]
+}
@@ -0,0 +1,227 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/jscottransom/fringe/internal/clock"
+	serial "github.com/jscottransom/fringe/internal/proto"
+	"github.com/jscottransom/fringe/internal/swim"
+)
+
+// Link describes the fault characteristics of messages sent from one address to another
+type Link struct {
+	Latency time.Duration
+	Loss    float64
+}
+
+// Network is an in-process network of SWIM transports with injectable latency, loss and partitions.
+// Latency does not advance virtual time; an exchange whose round trip exceeds the sender's
+// deadline on Clock is treated as lost. Each node handles one message at a time.
+type Network struct {
+	Default Link
+	Clock   clock.Clock
+
+	memory     *swim.MemoryNetwork
+	links      map[[2]string]Link
+	rngs       map[[2]string]*rand.Rand
+	partitions map[string]int
+	down       map[string]bool
+	receivers  map[string]*sync.Mutex
+	inFlight   int
+	idle       *sync.Cond
+	seed       int64
+	mu         sync.Mutex
+}
+
+// Creates a fault-free network whose random loss is derived from seed
+func NewNetwork(seed int64) *Network {
+	n := &Network{
+		memory:     swim.NewMemoryNetwork(),
+		links:      make(map[[2]string]Link),
+		rngs:       make(map[[2]string]*rand.Rand),
+		partitions: make(map[string]int),
+		down:       make(map[string]bool),
+		receivers:  make(map[string]*sync.Mutex),
+		seed:       seed,
+	}
+	n.idle = sync.NewCond(&n.mu)
+	return n
+}
+
+// Creates a transport reachable at addr whose traffic is subject to the network's faults
+func (n *Network) NewTransport(addr string) swim.Transport {
+	n.mu.Lock()
+	n.receivers[addr] = &sync.Mutex{}
+	n.mu.Unlock()
+
+	return &transport{
+		MemoryTransport: n.memory.NewTransport(addr),
+		network:         n,
+	}
+}
+
+// Overrides the default link characteristics for messages from one address to another
+func (n *Network) SetLink(from, to string, link Link) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.links[[2]string{from, to}] = link
+}
+
+// Splits the network so that only addresses in the same group can reach each other.
+// Addresses not listed in any group form one more group of their own.
+func (n *Network) Partition(groups ...[]string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.partitions = make(map[string]int)
+	for i, group := range groups {
+		for _, addr := range group {
+			n.partitions[addr] = i + 1
+		}
+	}
+}
+
+// Removes all partitions
+func (n *Network) Heal() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.partitions = make(map[string]int)
+}
+
+// Stops all traffic to and from the address, as if the node had crashed
+func (n *Network) Down(addr string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.down[addr] = true
+}
+
+// Restores traffic to and from an address taken down with Down
+func (n *Network) Up(addr string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.down, addr)
+}
+
+// Blocks until no exchange is in flight, so stragglers from one protocol step finish before the next
+func (n *Network) Wait() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for n.inFlight > 0 {
+		n.idle.Wait()
+	}
+}
+
+// Tracks the start or end of an exchange
+func (n *Network) track(delta int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.inFlight += delta
+	if n.inFlight == 0 {
+		n.idle.Broadcast()
+	}
+}
+
+// Reports whether a single message from one address to another is delivered within the budget,
+// along with how much of the budget it used
+func (n *Network) deliver(from, to string, budget time.Duration) (time.Duration, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.down[from] || n.down[to] || n.partitions[from] != n.partitions[to] {
+		return 0, false
+	}
+
+	key := [2]string{from, to}
+	link, ok := n.links[key]
+	if !ok {
+		link = n.Default
+	}
+	if link.Latency > budget {
+		return 0, false
+	}
+	if link.Loss > 0 && n.linkRand(key).Float64() < link.Loss {
+		return 0, false
+	}
+	return link.Latency, true
+}
+
+// Returns the random source for a link, seeded from the network seed and the link's endpoints
+// so loss on one link does not depend on traffic over others; the caller must hold the lock
+func (n *Network) linkRand(key [2]string) *rand.Rand {
+	if rng, ok := n.rngs[key]; ok {
+		return rng
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key[0]))
+	h.Write([]byte{0})
+	h.Write([]byte(key[1]))
+	rng := rand.New(rand.NewSource(n.seed ^ int64(h.Sum64())))
+	n.rngs[key] = rng
+	return rng
+}
+
+// Returns the network's clock, defaulting to wall clock time
+func (n *Network) getClock() clock.Clock {
+	if n.Clock == nil {
+		return clock.Real{}
+	}
+	return n.Clock
+}
+
+// Returns the lock serializing message handling at the address
+func (n *Network) receiver(addr string) *sync.Mutex {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.receivers[addr]
+}
+
+// transport wraps an in-memory transport and drops messages according to the network's faults
+type transport struct {
+	*swim.MemoryTransport
+	network *Network
+}
+
+// Reports that the transport carries one exchange at a time, so nodes using it act in a fixed order
+func (t *transport) Serial() bool {
+	return true
+}
+
+// Sends the envelope unless the request or its reply is dropped by the network
+func (t *transport) Send(ctx context.Context, addr string, msg *serial.Envelope) (*serial.Envelope, error) {
+	budget := time.Duration(1<<63 - 1)
+	if deadline, ok := ctx.Deadline(); ok {
+		budget = deadline.Sub(t.network.getClock().Now())
+	}
+
+	t.network.track(1)
+	defer t.network.track(-1)
+
+	from := t.Addr()
+	latency, ok := t.network.deliver(from, addr, budget)
+	if !ok {
+		return nil, fmt.Errorf("message to %s lost: %w", addr, context.DeadlineExceeded)
+	}
+
+	recv := t.network.receiver(addr)
+	if recv == nil {
+		return nil, fmt.Errorf("no transport at %s", addr)
+	}
+	// The network decides timeouts from latency, so the exchange itself runs to completion
+	// regardless of how much wall clock time has passed
+	recv.Lock()
+	reply, err := t.MemoryTransport.Send(context.WithoutCancel(ctx), addr, msg)
+	recv.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := t.network.deliver(addr, from, budget-latency); !ok {
+		return nil, fmt.Errorf("reply from %s lost: %w", addr, context.DeadlineExceeded)
+	}
+	return reply, nil
+}
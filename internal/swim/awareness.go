@@ -0,0 +1,56 @@
+package swim
+
+import (
+	"sync"
+	"time"
+)
+
+// awareness tracks this node's Lifeguard local health score. A score of 0 is healthy;
+// higher scores mean the node is missing its own deadlines and should be slower to accuse peers.
+type awareness struct {
+	score int
+	mu    sync.RWMutex
+}
+
+// Adjusts the score by delta, clamped to [0, max)
+func (a *awareness) applyDelta(delta, max int) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.score += delta
+	if a.score < 0 {
+		a.score = 0
+	} else if a.score > max-1 {
+		a.score = max - 1
+	}
+	return a.score
+}
+
+// Returns the current local health score
+func (a *awareness) getScore() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.score
+}
+
+// Adjusts the local health score when local health tracking is enabled
+func (n *Node) adjustHealth(delta int) {
+	cfg := n.Config.withDefaults()
+	if !cfg.LocalHealth || delta == 0 {
+		return
+	}
+	healthScore.Set(float64(n.health.applyDelta(delta, cfg.AwarenessMaxMultiplier)))
+}
+
+// Stretches a probe timeout or interval by the local health multiplier
+func (n *Node) scaleByHealth(d time.Duration) time.Duration {
+	if !n.Config.LocalHealth {
+		return d
+	}
+	return d * time.Duration(n.health.getScore()+1)
+}
+
+// Returns the Lifeguard local health score, where 0 is healthy
+func (n *Node) HealthScore() int {
+	return n.health.getScore()
+}
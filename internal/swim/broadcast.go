@@ -0,0 +1,149 @@
+package swim
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	serial "github.com/jscottransom/fringe/internal/proto"
+)
+
+// MaxUserMessageSize bounds the payload of a user broadcast so it fits alongside membership updates
+const MaxUserMessageSize = 512
+
+// ErrUserMessageTooLarge is returned when a broadcast payload exceeds MaxUserMessageSize
+var ErrUserMessageTooLarge = errors.New("user message too large")
+
+// UserMessage is an application payload spread through the cluster by gossip
+type UserMessage struct {
+	Name    string
+	Version uint64
+	Payload []byte
+	Origin  string
+}
+
+// userEntry tracks the dissemination of one user message
+type userEntry struct {
+	msg       *serial.UserMessage
+	transmits int
+	seen      map[string]bool
+}
+
+// userBroadcasts holds the user messages awaiting piggybacking and the newest version delivered per name
+type userBroadcasts struct {
+	entries   []*userEntry
+	delivered map[string]uint64
+	handler   func(UserMessage)
+	mu        sync.Mutex
+}
+
+// Sets the function called once for each new user message this node receives.
+// It runs on the protocol's message handling path and must not block.
+func (n *Node) HandleBroadcasts(handler func(UserMessage)) {
+	n.broadcasts.mu.Lock()
+	defer n.broadcasts.mu.Unlock()
+	n.broadcasts.handler = handler
+}
+
+// Queues a user message for gossip to every node. A message supersedes earlier versions with
+// the same name; versions not newer than one already seen are ignored by receivers.
+func (n *Node) Broadcast(name string, version uint64, payload []byte) error {
+	if len(payload) > MaxUserMessageSize {
+		return fmt.Errorf("failed to broadcast %s: %w", name, ErrUserMessageTooLarge)
+	}
+
+	msg := &serial.UserMessage{
+		Name:    name,
+		Version: version,
+		Payload: payload,
+		Origin:  n.NodeId,
+	}
+
+	n.broadcasts.mu.Lock()
+	defer n.broadcasts.mu.Unlock()
+	if n.broadcasts.delivered == nil {
+		n.broadcasts.delivered = make(map[string]uint64)
+	}
+	if last, ok := n.broadcasts.delivered[name]; ok && last >= version {
+		return fmt.Errorf("failed to broadcast %s: version %d is not newer than %d", name, version, last)
+	}
+	n.broadcasts.delivered[name] = version
+	n.broadcasts.enqueue(msg)
+	return nil
+}
+
+// Delivers user messages that are news to this node and re-gossips them
+func (n *Node) applyUserMessages(msgs []*serial.UserMessage) {
+	for _, msg := range msgs {
+		if len(msg.Payload) > MaxUserMessageSize {
+			continue
+		}
+
+		n.broadcasts.mu.Lock()
+		if n.broadcasts.delivered == nil {
+			n.broadcasts.delivered = make(map[string]uint64)
+		}
+		if last, ok := n.broadcasts.delivered[msg.Name]; ok && last >= msg.Version {
+			n.broadcasts.mu.Unlock()
+			continue
+		}
+		n.broadcasts.delivered[msg.Name] = msg.Version
+		n.broadcasts.enqueue(msg)
+		handler := n.broadcasts.handler
+		n.broadcasts.mu.Unlock()
+
+		messageCounter.WithLabelValues("user").Inc()
+		if handler != nil {
+			handler(UserMessage{
+				Name:    msg.Name,
+				Version: msg.Version,
+				Payload: msg.Payload,
+				Origin:  msg.Origin,
+			})
+		}
+	}
+}
+
+// Returns the user messages to piggyback to the target, least transmitted first, within budget bytes
+func (n *Node) pendingUserMessages(targetID string, budget int) []*serial.UserMessage {
+	limit := n.Queue.RetransmitLimit()
+
+	n.broadcasts.mu.Lock()
+	defer n.broadcasts.mu.Unlock()
+
+	sort.SliceStable(n.broadcasts.entries, func(i, j int) bool {
+		return n.broadcasts.entries[i].transmits < n.broadcasts.entries[j].transmits
+	})
+
+	var msgs []*serial.UserMessage
+	used := 0
+	kept := n.broadcasts.entries[:0]
+	for _, entry := range n.broadcasts.entries {
+		if !entry.seen[targetID] {
+			if size := encodedSize(entry.msg); used+size <= budget {
+				used += size
+				msgs = append(msgs, entry.msg)
+				entry.transmits++
+				entry.seen[targetID] = true
+			}
+		}
+		if entry.transmits < limit {
+			kept = append(kept, entry)
+		}
+	}
+	clear(n.broadcasts.entries[len(kept):])
+	n.broadcasts.entries = kept
+	return msgs
+}
+
+// Queues a message for dissemination, replacing any queued older version; the caller must hold the lock
+func (b *userBroadcasts) enqueue(msg *serial.UserMessage) {
+	for i, entry := range b.entries {
+		if entry.msg.Name == msg.Name {
+			b.entries = append(b.entries[:i], b.entries[i+1:]...)
+			break
+		}
+	}
+	b.entries = append([]*userEntry{{msg: msg, seen: make(map[string]bool)}}, b.entries...)
+}
@@ -0,0 +1,75 @@
+package swim
+
+import "time"
+
+// Config holds the tunable parameters of the SWIM failure detector
+type Config struct {
+	ProbeInterval    time.Duration
+	ProbeTimeout     time.Duration
+	IndirectChecks   int
+	SuspicionMult    int
+	LeaveFanout      int
+	PushPullInterval time.Duration
+	// GossipBytes caps the encoded size of the updates piggybacked on one message
+	GossipBytes int
+
+	// Lifeguard extensions, each of which can be toggled independently
+	LocalHealth             bool
+	AwarenessMaxMultiplier  int
+	DynamicSuspicion        bool
+	SuspicionMaxTimeoutMult int
+	BuddySystem             bool
+}
+
+// Returns the default failure detector configuration
+func DefaultConfig() Config {
+	return Config{
+		ProbeInterval:    5 * time.Second,
+		ProbeTimeout:     time.Second,
+		IndirectChecks:   3,
+		SuspicionMult:    4,
+		LeaveFanout:      3,
+		PushPullInterval: 30 * time.Second,
+		GossipBytes:      1024,
+
+		LocalHealth:             true,
+		AwarenessMaxMultiplier:  8,
+		DynamicSuspicion:        true,
+		SuspicionMaxTimeoutMult: 6,
+		BuddySystem:             true,
+	}
+}
+
+// Fills unset numeric fields with their defaults so a zero Config remains usable;
+// the Lifeguard toggles stay off unless set, which gives classic SWIM behaviour
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.ProbeInterval <= 0 {
+		c.ProbeInterval = def.ProbeInterval
+	}
+	if c.ProbeTimeout <= 0 {
+		c.ProbeTimeout = def.ProbeTimeout
+	}
+	if c.IndirectChecks <= 0 {
+		c.IndirectChecks = def.IndirectChecks
+	}
+	if c.SuspicionMult <= 0 {
+		c.SuspicionMult = def.SuspicionMult
+	}
+	if c.LeaveFanout <= 0 {
+		c.LeaveFanout = def.LeaveFanout
+	}
+	if c.PushPullInterval <= 0 {
+		c.PushPullInterval = def.PushPullInterval
+	}
+	if c.GossipBytes <= 0 {
+		c.GossipBytes = def.GossipBytes
+	}
+	if c.AwarenessMaxMultiplier <= 0 {
+		c.AwarenessMaxMultiplier = def.AwarenessMaxMultiplier
+	}
+	if c.SuspicionMaxTimeoutMult <= 0 {
+		c.SuspicionMaxTimeoutMult = def.SuspicionMaxTimeoutMult
+	}
+	return c
+}
@@ -0,0 +1,119 @@
+package swim
+
+import "sync"
+
+// EventType classifies a membership change
+type EventType int
+
+const (
+	// EventJoin reports a peer that was unknown, or had died or left, and is now a member
+	EventJoin EventType = iota
+	// EventUpdate reports a new incarnation of a member whose state did not meaningfully change
+	EventUpdate
+	// EventSuspect reports a peer becoming Suspected
+	EventSuspect
+	// EventDead reports a peer declared Dead
+	EventDead
+	// EventLeave reports a peer leaving the cluster gracefully
+	EventLeave
+	// EventRefute reports a suspicion being refuted, by a suspected peer or by this node about itself
+	EventRefute
+)
+
+// Returns the name of the event type
+func (e EventType) String() string {
+	switch e {
+	case EventJoin:
+		return "join"
+	case EventUpdate:
+		return "update"
+	case EventSuspect:
+		return "suspect"
+	case EventDead:
+		return "dead"
+	case EventLeave:
+		return "leave"
+	case EventRefute:
+		return "refute"
+	default:
+		return "unknown"
+	}
+}
+
+// MemberEvent describes a change to a peer in the member table.
+// OldState is only meaningful when the peer was known before the change.
+type MemberEvent struct {
+	Type     EventType
+	Peer     Peer
+	OldState NodeState
+	NewState NodeState
+}
+
+// eventBus fans membership events out to subscribers without blocking the protocol
+type eventBus struct {
+	subs map[int]chan MemberEvent
+	next int
+	mu   sync.RWMutex
+}
+
+// Classifies the change from prev to peer; prev has an empty PeerID if the peer was unknown
+func newMemberEvent(prev, peer Peer) MemberEvent {
+	event := MemberEvent{Peer: peer, OldState: prev.State, NewState: peer.State}
+	switch {
+	case prev.PeerID == "":
+		event.Type = EventJoin
+		event.OldState = peer.State
+	case peer.State == Suspected:
+		event.Type = EventSuspect
+	case peer.State == Dead:
+		event.Type = EventDead
+	case peer.State == Left:
+		event.Type = EventLeave
+	case prev.State == Suspected:
+		event.Type = EventRefute
+	case prev.State == Dead || prev.State == Left:
+		event.Type = EventJoin
+	default:
+		event.Type = EventUpdate
+	}
+	return event
+}
+
+// Returns a channel of membership events buffered to size and a function ending the subscription.
+// Events are dropped rather than stalling failure detection when the subscriber falls behind.
+func (n *Node) Subscribe(size int) (<-chan MemberEvent, func()) {
+	ch := make(chan MemberEvent, size)
+
+	n.events.mu.Lock()
+	if n.events.subs == nil {
+		n.events.subs = make(map[int]chan MemberEvent)
+	}
+	id := n.events.next
+	n.events.next++
+	n.events.subs[id] = ch
+	n.events.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			n.events.mu.Lock()
+			delete(n.events.subs, id)
+			n.events.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Delivers an event to every subscriber that has room for it
+func (n *Node) emit(event MemberEvent) {
+	n.events.mu.RLock()
+	defer n.events.mu.RUnlock()
+
+	for _, ch := range n.events.subs {
+		select {
+		case ch <- event:
+		default:
+			droppedEvents.Inc()
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package swim
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	serial "github.com/jscottransom/fringe/internal/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// MemoryNetwork connects in-memory transports by address within one process
+type MemoryNetwork struct {
+	transports map[string]*MemoryTransport
+	mu         sync.RWMutex
+}
+
+// MemoryTransport delivers envelopes to other transports on the same MemoryNetwork without sockets
+type MemoryTransport struct {
+	addr      string
+	network   *MemoryNetwork
+	incoming  chan *Message
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Creates an empty in-memory network
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{
+		transports: make(map[string]*MemoryTransport),
+	}
+}
+
+// Creates a transport reachable at addr on this network, replacing any closed one at the same address
+func (m *MemoryNetwork) NewTransport(addr string) *MemoryTransport {
+	t := &MemoryTransport{
+		addr:     addr,
+		network:  m,
+		incoming: make(chan *Message),
+		closed:   make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.transports[addr] = t
+	m.mu.Unlock()
+	return t
+}
+
+// Returns the transport listening at addr
+func (m *MemoryNetwork) lookup(addr string) (*MemoryTransport, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.transports[addr]
+	return t, ok
+}
+
+// Returns the address this transport is reachable at
+func (t *MemoryTransport) Addr() string {
+	return t.addr
+}
+
+// Hands a copy of the envelope to the transport at addr and waits for its reply
+func (t *MemoryTransport) Send(ctx context.Context, addr string, msg *serial.Envelope) (*serial.Envelope, error) {
+	select {
+	case <-t.closed:
+		return nil, ErrTransportClosed
+	default:
+	}
+
+	target, ok := t.network.lookup(addr)
+	if !ok {
+		return nil, fmt.Errorf("no transport at %s", addr)
+	}
+
+	replies := make(chan *serial.Envelope, 1)
+	in := NewMessage(proto.Clone(msg).(*serial.Envelope), t.addr, func(reply *serial.Envelope) error {
+		if reply == nil {
+			reply = &serial.Envelope{}
+		}
+		replies <- proto.Clone(reply).(*serial.Envelope)
+		return nil
+	})
+
+	select {
+	case target.incoming <- in:
+	case <-target.closed:
+		return nil, fmt.Errorf("transport at %s: %w", addr, ErrTransportClosed)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no reply from %s: %w", addr, ctx.Err())
+	}
+
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no reply from %s: %w", addr, ctx.Err())
+	case <-t.closed:
+		return nil, ErrTransportClosed
+	}
+}
+
+// Returns the channel of incoming messages
+func (t *MemoryTransport) Receive() <-chan *Message {
+	return t.incoming
+}
+
+// Detaches the transport from the network
+func (t *MemoryTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+		t.network.mu.Lock()
+		if t.network.transports[t.addr] == t {
+			delete(t.network.transports, t.addr)
+		}
+		t.network.mu.Unlock()
+	})
+	return nil
+}
@@ -0,0 +1,75 @@
+package swim
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+
+	serial "github.com/jscottransom/fringe/internal/proto"
+)
+
+// MaxMetaSize bounds the total bytes of keys and values in a node's metadata so it fits in piggybacked updates
+const MaxMetaSize = 512
+
+// ErrMetaTooLarge is returned when metadata exceeds MaxMetaSize
+var ErrMetaTooLarge = errors.New("metadata too large")
+
+// Returns the total bytes of keys and values in the metadata
+func metaSize(meta map[string]string) int {
+	size := 0
+	for key, value := range meta {
+		size += len(key) + len(value)
+	}
+	return size
+}
+
+// Returns the update's metadata if it is within the size limit, dropping oversized metadata from misbehaving peers
+func acceptMeta(update *serial.MembershipUpdate) map[string]string {
+	if size := metaSize(update.Meta); size > MaxMetaSize {
+		log.Printf("Ignoring %d bytes of metadata from %s: %v", size, update.NodeId, ErrMetaTooLarge)
+		return nil
+	}
+	return maps.Clone(update.Meta)
+}
+
+// Replaces this node's metadata and gossips it under a new incarnation so peers adopt it.
+// Metadata maps are never modified in place, so Peer copies may share them safely.
+func (n *Node) SetMeta(meta map[string]string) error {
+	if size := metaSize(meta); size > MaxMetaSize {
+		return fmt.Errorf("failed to set %d bytes of metadata: %w", size, ErrMetaTooLarge)
+	}
+
+	n.MemberTable.mu.Lock()
+	self := n.MemberTable.Members[n.NodeId]
+	if self == nil {
+		n.MemberTable.mu.Unlock()
+		return fmt.Errorf("node %s missing from member table", n.NodeId)
+	}
+	prev := *self
+	self.Incarnation++
+	self.Meta = maps.Clone(meta)
+	updated := *self
+	n.MemberTable.mu.Unlock()
+
+	n.emit(newMemberEvent(prev, updated))
+	n.enqueueUpdate(updated, serial.State(updated.State))
+	return nil
+}
+
+// Returns the Alive and Suspected peers whose metadata has the given tag value
+func (n *NodeTable) GetPeersByTag(key, value string) []Peer {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	var peers []Peer
+	for _, peer := range n.Members {
+		if peer.State != Alive && peer.State != Suspected {
+			continue
+		}
+		if tag, ok := peer.Meta[key]; ok && tag == value {
+			peers = append(peers, *peer)
+		}
+	}
+	return peers
+}
@@ -2,18 +2,18 @@ package swim
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net"
+	"math/rand"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/jscottransom/fringe/internal/clock"
 	serial "github.com/jscottransom/fringe/internal/proto"
 	"github.com/prometheus/client_golang/prometheus"
-	quic "github.com/quic-go/quic-go"
-	"google.golang.org/protobuf/proto"
 )
 
 const PeerTTL = 60 * time.Second
@@ -30,8 +30,26 @@ var (
 		Name: "fringe_messages_total",
 		Help: "Total number of messages by type",
 	}, []string{"type"})
+
+	healthScore = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "fringe_local_health_score",
+		Help: "Lifeguard local health score, 0 is healthy",
+	})
+
+	droppedEvents = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "fringe_member_events_dropped_total",
+		Help: "Membership events dropped because a subscriber was not keeping up",
+	})
 )
 
+// Returns every swim metric, for the binary to register
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{pingLatency, messageCounter, healthScore, droppedEvents}
+}
+
+// errNack reports that a helper answered an indirect probe but could not reach the target
+var errNack = errors.New("nack")
+
 // NodeState represents the state of a node in the cluster
 type NodeState int
 
@@ -49,6 +67,7 @@ type Peer struct {
 	State            NodeState
 	Incarnation      uint64
 	SinceStateUpdate time.Time
+	Meta             map[string]string
 }
 
 // Node represents a Fringe node in the SWIM cluster with member table and gossip protocol
@@ -58,15 +77,31 @@ type Node struct {
 	Queue       *PiggyBackQueue
 	Addr        string
 	Bootstrap   bool
+	Config      Config
+	Transport   Transport
+	Clock       clock.Clock
+	Rand        *rand.Rand
 	mu          sync.RWMutex
+	randMu      sync.Mutex
 	ctx         context.Context
 	cancel      context.CancelFunc
+	health      awareness
+	probeOrder  []string
+	probeIndex  int
+	probeMu     sync.Mutex
+	events      eventBus
+	broadcasts  userBroadcasts
+	seeds       []string
+
+	messageHandler func(*serial.Envelope) *serial.Envelope
 }
 
 // NodeTable maps node IDs to Peer objects with thread-safe operations
 type NodeTable struct {
-	Members map[string]*Peer
-	mu      sync.RWMutex
+	Members    map[string]*Peer
+	Clock      clock.Clock
+	mu         sync.RWMutex
+	suspicions map[string]*suspicion
 }
 
 // Safely adds a peer to the member table with thread-safe access
@@ -77,34 +112,68 @@ func (n *NodeTable) AddPeer(nodeID string, peer *Peer) {
 	fmt.Printf("Successfully added %s to Node Table", nodeID)
 }
 
-// Safely updates a peer's state based on membership updates with incarnation handling
-func (n *NodeTable) UpdatePeer(update *serial.MembershipUpdate, suspect bool) {
+// Safely updates a peer's state based on membership updates with incarnation handling.
+// Unknown peers announced as Alive or Suspected are inserted.
+// Returns a copy of the peer and whether it was inserted or its state or incarnation changed.
+func (n *NodeTable) UpdatePeer(update *serial.MembershipUpdate, suspect bool) (Peer, bool) {
+	_, peer, changed := n.updatePeer(update, suspect)
+	return peer, changed
+}
+
+// Applies a membership update like UpdatePeer, also returning the peer as it was before the update.
+// The previous peer has an empty PeerID if the peer was unknown.
+func (n *NodeTable) updatePeer(update *serial.MembershipUpdate, suspect bool) (Peer, Peer, bool) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
 	peer := n.Members[update.NodeId]
 	if peer == nil {
-		return
+		if update.State != serial.State_ALIVE && update.State != serial.State_SUSPECT {
+			return Peer{}, Peer{}, false
+		}
+		peer = &Peer{
+			PeerID:           update.NodeId,
+			Address:          update.Address,
+			State:            NodeState(update.State),
+			Incarnation:      update.Incarnation,
+			SinceStateUpdate: n.getClock().Now(),
+			Meta:             acceptMeta(update),
+		}
+		n.Members[update.NodeId] = peer
+		return Peer{}, *peer, true
 	}
 
+	prev := *peer
+	prevState, prevIncarnation := peer.State, peer.Incarnation
 	switch {
 	case peer.Incarnation > update.Incarnation:
-		return
+		return prev, *peer, false
 	case peer.Incarnation < update.Incarnation:
 		peer.Incarnation = update.Incarnation
 		peer.State = NodeState(*update.State.Enum())
+		peer.Meta = acceptMeta(update)
 	case peer.Incarnation == update.Incarnation:
 		peer.State = max(peer.State, NodeState(*update.State.Enum()))
 	}
 
+	changed := peer.State != prevState || peer.Incarnation != prevIncarnation
+	if peer.State != prevState {
+		peer.SinceStateUpdate = n.getClock().Now()
+	}
+	if changed && peer.State != Suspected {
+		n.stopSuspicion(update.NodeId)
+	}
+
 	for _, state := range []NodeState{Suspected, Dead, Left} {
-		if (peer.State == state) && (time.Since(peer.SinceStateUpdate) > PeerTTL) {
+		if (peer.State == state) && (n.getClock().Since(peer.SinceStateUpdate) > PeerTTL) {
+			n.stopSuspicion(update.NodeId)
 			delete(n.Members, update.NodeId)
 		}
 	}
+	return prev, *peer, changed
 }
 
-// Returns a list of alive peers for ping selection with read-safe access
+// Returns a list of alive peers for ping selection, ordered by ID so seeded random picks are reproducible
 func (n *NodeTable) GetAlivePeers() []*Peer {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
@@ -115,6 +184,7 @@ func (n *NodeTable) GetAlivePeers() []*Peer {
 			alivePeers = append(alivePeers, peer)
 		}
 	}
+	sort.Slice(alivePeers, func(i, j int) bool { return alivePeers[i].PeerID < alivePeers[j].PeerID })
 	return alivePeers
 }
 
@@ -132,350 +202,627 @@ func (n *NodeTable) GetClusterSize() int {
 	return count
 }
 
-// Initializes and starts the SWIM gossip protocol with periodic ping and cleanup
-func (n *Node) StartGossip(ctx context.Context) {
-	n.ctx = ctx
+// Returns a copy of the peer with the given ID
+func (n *NodeTable) GetPeer(nodeID string) (Peer, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	peer, ok := n.Members[nodeID]
+	if !ok {
+		return Peer{}, false
+	}
+	return *peer, true
+}
+
+// Returns a copy of every peer in the member table, ordered by ID
+func (n *NodeTable) Snapshot() []Peer {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	peers := make([]Peer, 0, len(n.Members))
+	for _, peer := range n.Members {
+		peers = append(peers, *peer)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].PeerID < peers[j].PeerID })
+	return peers
+}
+
+// Removes Dead and Left peers whose state has not changed for longer than the TTL
+func (n *NodeTable) RemoveExpired(ttl time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for id, peer := range n.Members {
+		if (peer.State == Dead || peer.State == Left) && n.getClock().Since(peer.SinceStateUpdate) > ttl {
+			n.stopSuspicion(id)
+			delete(n.Members, id)
+		}
+	}
+}
+
+// Initializes and starts the SWIM gossip protocol with periodic ping and cleanup on the node's
+// clock; the loops stop when the context is cancelled
+func (n *Node) StartGossip(ctx context.Context) error {
+	if n.Transport == nil {
+		return fmt.Errorf("node %s has no transport", n.NodeId)
+	}
+
+	n.mu.Lock()
+	n.ctx, n.cancel = context.WithCancel(ctx)
+	n.mu.Unlock()
 
-	go n.periodicPing()
-	go n.periodicCleanup()
+	n.periodicPing()
+	n.periodicCleanup()
+	n.periodicPushPull()
 
 	log.Printf("Started gossip protocol for node %s", n.NodeId)
+	return nil
 }
 
-// Attempts to join an existing cluster via a known node with ping-based discovery
+// Attempts to join an existing cluster via a known node with a ping followed by a full state exchange
 func (n *Node) JoinCluster(knownNodeAddr string) error {
 	log.Printf("Joining cluster via node: %s", knownNodeAddr)
 
+	updates, userMessages := n.piggyback(knownNodeAddr)
 	ping := &serial.Ping{
 		SenderId:      n.NodeId,
 		SenderAddress: n.Addr,
 		TargetId:      knownNodeAddr,
-		Updates:       []*serial.MembershipUpdate{},
+		Updates:       updates,
+		UserMessages:  userMessages,
 	}
 
-	return n.sendPing(ping)
-}
+	ctx, cancel := clock.WithTimeout(context.Background(), n.getClock(), messageTimeout)
+	defer cancel()
 
-// Sends periodic pings to random peers every 5 seconds for failure detection
-func (n *Node) periodicPing() {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+	if err := n.sendPing(ctx, knownNodeAddr, ping); err != nil {
+		return err
+	}
 
-	for {
-		select {
-		case <-n.ctx.Done():
-			return
-		case <-ticker.C:
-			n.sendRandomPing()
-		}
+	n.mu.Lock()
+	if !slices.Contains(n.seeds, knownNodeAddr) {
+		n.seeds = append(n.seeds, knownNodeAddr)
 	}
+	n.mu.Unlock()
+
+	// Exchange full membership so the newcomer does not depend on piggybacking alone
+	return n.sendPushPull(ctx, knownNodeAddr, true)
 }
 
-// Selects a random alive peer and sends a ping with piggybacked updates
-func (n *Node) sendRandomPing() {
-	alivePeers := n.MemberTable.GetAlivePeers()
-	if len(alivePeers) == 0 {
-		return
+// Gracefully leaves the cluster by gossiping a LEFT update and waiting for peers to acknowledge it until the context expires
+func (n *Node) Leave(ctx context.Context) error {
+	n.MemberTable.mu.Lock()
+	self := n.MemberTable.Members[n.NodeId]
+	if self == nil {
+		n.MemberTable.mu.Unlock()
+		return fmt.Errorf("node %s missing from member table", n.NodeId)
+	}
+	self.Incarnation++
+	self.State = Left
+	self.SinceStateUpdate = n.MemberTable.getClock().Now()
+	left := *self
+	n.MemberTable.mu.Unlock()
+
+	log.Printf("Leaving cluster as %s at incarnation %d", n.NodeId, left.Incarnation)
+
+	// Stop probing so the node does not suspect peers while shutting down
+	n.mu.Lock()
+	if n.cancel != nil {
+		n.cancel()
 	}
+	n.mu.Unlock()
 
-	// Find first non-self peer
-	var targetPeer *Peer
-	for _, peer := range alivePeers {
+	n.enqueueUpdate(left, serial.State_LEFT)
+	leftUpdate := newUpdate(left, serial.State_LEFT)
+
+	var peers []*Peer
+	for _, peer := range n.MemberTable.GetAlivePeers() {
 		if peer.PeerID != n.NodeId {
-			targetPeer = peer
-			break
+			peers = append(peers, peer)
 		}
 	}
-	if targetPeer == nil {
-		return
+	if len(peers) == 0 {
+		return nil
 	}
-
-	// Build updates
-	updates := n.Queue.GetEntries(n.NodeId, 5)
-	serialUpdates := make([]*serial.MembershipUpdate, len(updates))
-	for i, entry := range updates {
-		serialUpdates[i] = entry.Update
+	n.shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+	if fanout := n.Config.withDefaults().LeaveFanout; len(peers) > fanout {
+		peers = peers[:fanout]
 	}
 
-	ping := &serial.Ping{
-		SenderId:      n.NodeId,
-		SenderAddress: n.Addr,
-		TargetId:      targetPeer.Address,
-		Updates:       serialUpdates,
+	acks := make(chan error, len(peers))
+	for _, peer := range peers {
+		ping := &serial.Ping{
+			SenderId:      n.NodeId,
+			SenderAddress: n.Addr,
+			TargetId:      peer.PeerID,
+			Updates:       []*serial.MembershipUpdate{leftUpdate},
+		}
+		notify := func() {
+			acks <- n.sendPing(ctx, peer.Address, ping)
+		}
+		if n.serialTransport() {
+			notify()
+			continue
+		}
+		go notify()
 	}
 
-	start := time.Now()
-	if err := n.sendPing(ping); err != nil {
-		log.Printf("Failed to ping %s: %v", targetPeer.Address, err)
-	} else {
-		pingLatency.Observe(time.Since(start).Seconds())
-		messageCounter.WithLabelValues("ping").Inc()
+	acked := 0
+	for range peers {
+		select {
+		case err := <-acks:
+			if err != nil {
+				log.Printf("Leave notification failed: %v", err)
+				continue
+			}
+			acked++
+		case <-ctx.Done():
+			return fmt.Errorf("leave timed out after %d of %d acks: %w", acked, len(peers), ctx.Err())
+		}
+	}
+	if acked == 0 {
+		return fmt.Errorf("no peer acknowledged leave of %s", n.NodeId)
 	}
+	return nil
 }
 
-// Removes expired entries and updates metrics every 10 seconds
-func (n *Node) periodicCleanup() {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
+// Probes one peer per protocol period, stretched by the local health score
+func (n *Node) periodicPing() {
+	n.every(func() time.Duration {
+		return n.scaleByHealth(n.Config.withDefaults().ProbeInterval)
+	}, n.probeNext)
+}
 
-	for {
-		select {
-		case <-n.ctx.Done():
-			return
-		case <-ticker.C:
-			n.Queue.EvictEntry()
-		}
+// Probes the next peer in the randomized round-robin order for failure detection
+func (n *Node) probeNext() {
+	target, ok := n.nextProbeTarget()
+	if !ok {
+		return
 	}
+	n.probePeer(target)
 }
 
-// Establishes a QUIC connection and stream to the target address with TLS configuration
-func (n *Node) initUDPStream(addr string) (*quic.Stream, error) {
-	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{Port: 0})
-	if err != nil {
-		return nil, fmt.Errorf("failed to listen UDP: %w", err)
+// Probes a peer directly, falling back to k indirect probes before marking it Suspected
+func (n *Node) probePeer(target Peer) {
+	cfg := n.Config.withDefaults()
+	start := n.getClock().Now()
+	probeTimeout := n.scaleByHealth(cfg.ProbeTimeout)
+
+	ctx, cancel := clock.WithTimeout(n.ctx, n.getClock(), probeTimeout)
+	err := n.sendPing(ctx, target.Address, n.buildPing(target))
+	cancel()
+	if err == nil {
+		pingLatency.Observe(n.getClock().Since(start).Seconds())
+		messageCounter.WithLabelValues("ping").Inc()
+		n.adjustHealth(-1)
+		return
 	}
-	defer udpConn.Close()
+	log.Printf("Direct ping to %s failed: %v", target.Address, err)
 
-	tr := &quic.Transport{Conn: udpConn}
-	defer tr.Close()
+	// Indirect probes may use whatever remains of the protocol period
+	indirectTimeout := n.scaleByHealth(cfg.ProbeInterval) - n.getClock().Since(start)
+	if indirectTimeout < probeTimeout {
+		indirectTimeout = probeTimeout
+	}
+	acked, missedNacks := n.indirectProbe(target, cfg.IndirectChecks, indirectTimeout)
+	if acked {
+		return
+	}
+	if n.ctx.Err() != nil {
+		return
+	}
+
+	// Helpers that never answered suggest this node, not the target, is struggling
+	n.adjustHealth(missedNacks)
 
-	tlsConf := &tls.Config{
-		InsecureSkipVerify: true,
-		NextProtos:         []string{"quic"},
+	log.Printf("No direct or indirect ack from %s, marking suspected", target.Address)
+	if err := n.handleNack(target.PeerID); err != nil {
+		log.Printf("Failed to handle nack for %s: %v", target.PeerID, err)
 	}
+}
 
-	quicConf := &quic.Config{
-		HandshakeIdleTimeout: 30 * time.Second,
+// Asks up to k random alive peers to ping the target, reporting whether any of them got an ack
+// and how many helpers failed to answer with even a nack
+func (n *Node) indirectProbe(target Peer, k int, timeout time.Duration) (bool, int) {
+	var helpers []*Peer
+	for _, peer := range n.MemberTable.GetAlivePeers() {
+		if peer.PeerID != n.NodeId && peer.PeerID != target.PeerID {
+			helpers = append(helpers, peer)
+		}
+	}
+	if len(helpers) == 0 {
+		return false, 0
+	}
+	n.shuffle(len(helpers), func(i, j int) {
+		helpers[i], helpers[j] = helpers[j], helpers[i]
+	})
+	if len(helpers) > k {
+		helpers = helpers[:k]
+	}
+
+	ctx, cancel := clock.WithTimeout(n.ctx, n.getClock(), timeout)
+	defer cancel()
+
+	results := make(chan error, len(helpers))
+	for _, helper := range helpers {
+		pingReq := &serial.PingReq{
+			SenderId:       n.NodeId,
+			SenderAddress:  n.Addr,
+			TargetId:       target.PeerID,
+			TargetAddress:  target.Address,
+			RequestId:      helper.PeerID,
+			RequestAddress: helper.Address,
+			Updates:        n.pendingUpdates(helper.PeerID),
+		}
+		probe := func() {
+			err := n.sendPingReq(ctx, pingReq)
+			if err != nil {
+				log.Printf("Indirect probe of %s via %s failed: %v", target.Address, pingReq.RequestAddress, err)
+			}
+			results <- err
+		}
+		// On a serial transport helpers are asked in turn, so results arrive in helper order
+		if n.serialTransport() {
+			probe()
+			continue
+		}
+		go probe()
 	}
 
-	udpAddr, err := net.ResolveUDPAddr("udp", addr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve address: %w", err)
+	nacks := 0
+	for range helpers {
+		select {
+		case err := <-results:
+			if err == nil {
+				messageCounter.WithLabelValues("ping_req").Inc()
+				return true, 0
+			}
+			if errors.Is(err, errNack) {
+				nacks++
+			}
+		case <-ctx.Done():
+			return false, len(helpers) - nacks
+		}
 	}
+	return false, len(helpers) - nacks
+}
 
-	conn, err := tr.Dial(context.Background(), udpAddr, tlsConf, quicConf)
-	if err != nil {
-		return nil, fmt.Errorf("failed to dial QUIC connection: %w", err)
+// Builds a ping to the target carrying pending piggybacked updates.
+// With the buddy system enabled, a suspected target is told about its own suspicion first.
+func (n *Node) buildPing(target Peer) *serial.Ping {
+	updates, userMessages := n.piggyback(target.PeerID)
+	if target.State == Suspected && n.Config.BuddySystem {
+		suspect := newUpdate(target, serial.State_SUSPECT)
+		suspect.From = n.NodeId
+		updates = append([]*serial.MembershipUpdate{suspect}, updates...)
 	}
-	defer conn.CloseWithError(quic.ApplicationErrorCode(0), "")
 
-	stream, err := conn.OpenStreamSync(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("failed to open stream: %w", err)
+	return &serial.Ping{
+		SenderId:      n.NodeId,
+		SenderAddress: n.Addr,
+		TargetId:      target.PeerID,
+		Updates:       updates,
+		UserMessages:  userMessages,
 	}
+}
 
-	return stream, nil
+// Returns the membership updates and user messages to piggyback on the next outgoing message to
+// the target; user messages share whatever gossip budget the membership updates leave
+func (n *Node) piggyback(targetID string) ([]*serial.MembershipUpdate, []*serial.UserMessage) {
+	updates := n.pendingUpdates(targetID)
+	budget := n.Config.withDefaults().GossipBytes
+	for _, update := range updates {
+		budget -= encodedSize(update)
+	}
+	return updates, n.pendingUserMessages(targetID, budget)
 }
 
-// Sends a ping message to a target node and handles the response with timeout
-func (n *Node) sendPing(ping *serial.Ping) error {
-	stream, err := n.initUDPStream(ping.TargetId)
-	if err != nil {
-		return fmt.Errorf("failed to open stream to %s: %w", ping.TargetId, err)
+// Returns the membership updates to piggyback on the next outgoing message to the target
+func (n *Node) pendingUpdates(targetID string) []*serial.MembershipUpdate {
+	entries := n.Queue.GetEntriesWithin(targetID, n.Config.withDefaults().GossipBytes)
+	updates := make([]*serial.MembershipUpdate, len(entries))
+	for i, entry := range entries {
+		updates[i] = entry.Update
 	}
-	defer stream.Close()
+	return updates
+}
 
-	stream.SetDeadline(time.Now().Add(3 * time.Second))
+// Removes expired queue entries and reaps long-gone peers every 10 seconds
+func (n *Node) periodicCleanup() {
+	n.every(func() time.Duration { return 10 * time.Second }, func() {
+		n.Queue.EvictEntry()
+		n.MemberTable.RemoveExpired(PeerTTL)
+	})
+}
 
-	data, err := proto.Marshal(ping)
-	if err != nil {
-		return fmt.Errorf("failed to marshal ping: %w", err)
+// Runs fn after each interval on the node's clock until gossip is stopped
+func (n *Node) every(interval func() time.Duration, fn func()) {
+	ctx := n.ctx
+	var tick func()
+	tick = func() {
+		if ctx.Err() != nil {
+			return
+		}
+		fn()
+		n.getClock().AfterFunc(interval(), tick)
 	}
+	n.getClock().AfterFunc(interval(), tick)
+}
 
-	if _, err := stream.Write(data); err != nil {
-		return fmt.Errorf("failed to write ping to %s: %w", ping.TargetId, err)
+// Returns the table's clock, defaulting to wall clock time
+func (n *NodeTable) getClock() clock.Clock {
+	if n.Clock == nil {
+		return clock.Real{}
 	}
+	return n.Clock
+}
 
-	resp, err := io.ReadAll(stream)
-	if err != nil {
-		return fmt.Errorf("failed to read response from %s: %w", ping.TargetId, err)
+// Returns the node's clock, defaulting to wall clock time
+func (n *Node) getClock() clock.Clock {
+	if n.Clock == nil {
+		return clock.Real{}
 	}
+	return n.Clock
+}
 
-	var ack serial.Ack
-	if err := proto.Unmarshal(resp, &ack); err != nil {
-		return n.handleAck(&ack)
-	} else {
-		return n.handleNack(ping.TargetId)
+// Returns a pseudo-random number in [0, k) from the node's source, defaulting to the global one
+func (n *Node) randIntn(k int) int {
+	if n.Rand == nil {
+		return rand.Intn(k)
 	}
+	n.randMu.Lock()
+	defer n.randMu.Unlock()
+	return n.Rand.Intn(k)
 }
 
-// Processes incoming ping messages and sends acknowledgments with piggybacked updates
-func (n *Node) handlePing(sess *quic.Conn) {
-	stream, err := sess.AcceptStream(context.Background())
-	if err != nil {
-		log.Printf("failed to accept stream: %v", err)
+// Shuffles k elements with the node's source, defaulting to the global one
+func (n *Node) shuffle(k int, swap func(i, j int)) {
+	if n.Rand == nil {
+		rand.Shuffle(k, swap)
 		return
 	}
+	n.randMu.Lock()
+	defer n.randMu.Unlock()
+	n.Rand.Shuffle(k, swap)
+}
 
-	go func() {
-		defer stream.Close()
-
-		data, err := io.ReadAll(stream)
-		if err != nil {
-			log.Printf("failed to read stream: %v", err)
-			return
-		}
+// Sends an envelope to the target address over the node's transport and returns the peer's reply envelope
+func (n *Node) exchange(ctx context.Context, addr string, env *serial.Envelope) (*serial.Envelope, error) {
+	if n.Transport == nil {
+		return nil, fmt.Errorf("node %s has no transport", n.NodeId)
+	}
+	return n.Transport.Send(ctx, addr, env)
+}
 
-		var ping serial.Ping
-		if err := proto.Unmarshal(data, &ping); err != nil {
-			log.Printf("failed to Unmarshal Ping: %v", err)
-			return
-		}
+// Sends a ping message to a target node and handles the ack within the context deadline
+func (n *Node) sendPing(ctx context.Context, addr string, ping *serial.Ping) error {
+	reply, err := n.exchange(ctx, addr, &serial.Envelope{Msg: &serial.Envelope_Ping{Ping: ping}})
+	if err != nil {
+		return err
+	}
 
-		if ping.SenderId == n.NodeId {
-			log.Printf("ignoring ping from self (%s)", ping.SenderId)
-			return
-		}
+	ack := reply.GetAck()
+	if ack == nil || ack.Response != ackResponse {
+		return fmt.Errorf("no ack from %s", addr)
+	}
+	return n.handleAck(ack)
+}
 
-		// Build updates
-		entries := n.Queue.GetEntries(n.NodeId, 5)
-		updates := make([]*serial.MembershipUpdate, len(entries))
-		for i, entry := range entries {
-			updates[i] = entry.Update
-		}
+// Processes incoming ping messages and builds an acknowledgment with piggybacked updates
+func (n *Node) handlePing(ping *serial.Ping) *serial.Ack {
+	if ping.SenderId == n.NodeId {
+		log.Printf("ignoring ping from self (%s)", ping.SenderId)
+		return nil
+	}
 
-		ack := &serial.Ack{
-			Response:      "Ack",
-			SenderId:      n.NodeId,
-			SenderAddress: n.MemberTable.Members[n.NodeId].Address,
-			Incarnation:   n.MemberTable.Members[n.NodeId].Incarnation,
-			TargetId:      ping.SenderId,
-			Updates:       updates,
-		}
+	n.applyUpdates(ping.Updates)
+	n.applyUserMessages(ping.UserMessages)
 
-		ackData, err := proto.Marshal(ack)
-		if err != nil {
-			log.Printf("failed to marshal ack: %v", err)
-			return
-		}
-		if _, err := stream.Write(ackData); err != nil {
-			stream.Write([]byte("Nack"))
-			log.Printf("failed to write ack to stream: %v", err)
-			return
-		}
-	}()
+	return n.buildAck(ping.SenderId, ackResponse)
 }
 
-// Sends a ping request to probe a target node via an intermediate node with timeout
-func (n *Node) sendPingReq(pingReq *serial.PingReq) error {
-	stream, err := n.initUDPStream(pingReq.RequestAddress)
+// Sends a ping request asking an intermediate node to probe the target within the context deadline
+func (n *Node) sendPingReq(ctx context.Context, pingReq *serial.PingReq) error {
+	reply, err := n.exchange(ctx, pingReq.RequestAddress, &serial.Envelope{Msg: &serial.Envelope_PingReq{PingReq: pingReq}})
 	if err != nil {
-		return fmt.Errorf("failed to open stream to %s: %w", pingReq.RequestAddress, err)
+		return err
 	}
-	defer stream.Close()
-
-	stream.SetDeadline(time.Now().Add(3 * time.Second))
 
-	message, err := proto.Marshal(pingReq)
-	if err != nil {
-		return fmt.Errorf("failed to marshal Ping Req to %s: %w", pingReq.RequestAddress, err)
+	ack := reply.GetAck()
+	if ack == nil {
+		return fmt.Errorf("no indirect ack for %s via %s", pingReq.TargetId, pingReq.RequestAddress)
 	}
-	_, err = stream.Write([]byte(message))
-	if err != nil {
-		return fmt.Errorf("failed to write to stream: %v", err)
+	if ack.Response != ackResponse {
+		return fmt.Errorf("%w for %s via %s", errNack, pingReq.TargetId, pingReq.RequestAddress)
 	}
+	return n.handleAck(ack)
+}
 
-	resp, err := io.ReadAll(stream)
-	if err != nil {
-		return fmt.Errorf("failed to read from stream: %v", err)
+// Processes incoming ping requests by probing the target node on behalf of the sender
+func (n *Node) handlePingReq(pingReq *serial.PingReq) *serial.Ack {
+	if pingReq.SenderId == n.NodeId {
+		log.Printf("ignoring ping from self (%s)", pingReq.SenderId)
+		return nil
 	}
 
-	var ack serial.Ack
-	if err := proto.Unmarshal(resp, &ack); err != nil {
-		return n.handleAck(&ack)
-	} else {
-		return n.handleNack(pingReq.RequestId)
+	n.applyUpdates(pingReq.Updates)
+
+	updates, userMessages := n.piggyback(pingReq.TargetId)
+	ping := &serial.Ping{
+		SenderId:      n.NodeId,
+		SenderAddress: n.Addr,
+		TargetId:      pingReq.TargetId,
+		Updates:       updates,
+		UserMessages:  userMessages,
+	}
+
+	// Leave room to deliver the nack before the requester gives up
+	timeout := n.scaleByHealth(n.Config.withDefaults().ProbeTimeout) * 4 / 5
+	ctx, cancel := clock.WithTimeout(context.Background(), n.getClock(), timeout)
+	defer cancel()
+
+	if err := n.sendPing(ctx, pingReq.TargetAddress, ping); err != nil {
+		return n.buildAck(pingReq.SenderId, nackResponse)
 	}
+	return n.buildAck(pingReq.SenderId, ackResponse)
 }
 
-// Processes incoming ping requests and forwards them to target nodes with piggybacked updates
-func (n *Node) handlePingReq(sess *quic.Conn) {
-	stream, err := sess.AcceptStream(context.Background())
-	if err != nil {
-		log.Printf("failed to accept stream: %v", err)
-		return
+// Builds an Ack addressed to the target node carrying pending piggybacked updates
+func (n *Node) buildAck(targetID, response string) *serial.Ack {
+	n.MemberTable.mu.RLock()
+	var incarnation uint64
+	var meta map[string]string
+	if self := n.MemberTable.Members[n.NodeId]; self != nil {
+		incarnation, meta = self.Incarnation, self.Meta
 	}
+	n.MemberTable.mu.RUnlock()
 
-	go func() {
-		defer stream.Close()
+	updates, userMessages := n.piggyback(targetID)
+	return &serial.Ack{
+		Response:      response,
+		SenderId:      n.NodeId,
+		SenderAddress: n.Addr,
+		Incarnation:   incarnation,
+		TargetId:      targetID,
+		Updates:       updates,
+		Meta:          meta,
+		UserMessages:  userMessages,
+	}
+}
 
-		data, err := io.ReadAll(stream)
-		if err != nil {
-			log.Printf("failed to read stream: %v", err)
-			return
-		}
+// Processes acknowledgment messages and updates member table with received updates
+func (n *Node) handleAck(ack *serial.Ack) error {
+	// An ack is first-hand proof that the sender is alive at its incarnation
+	n.applyUpdate(&serial.MembershipUpdate{
+		NodeId:      ack.SenderId,
+		Address:     ack.SenderAddress,
+		Incarnation: ack.Incarnation,
+		State:       serial.State_ALIVE,
+		Meta:        ack.Meta,
+	})
+	n.applyUpdates(ack.Updates)
+	n.applyUserMessages(ack.UserMessages)
+	return nil
+}
 
-		var pingReq serial.PingReq
-		if err := proto.Unmarshal(data, &pingReq); err != nil {
-			log.Printf("failed to Unmarshal PingReq: %v", err)
-			return
-		}
+// Applies piggybacked membership updates to the member table
+func (n *Node) applyUpdates(updates []*serial.MembershipUpdate) {
+	for _, update := range updates {
+		n.applyUpdate(update)
+	}
+}
 
-		if pingReq.SenderId == n.NodeId {
-			log.Printf("ignoring ping from self (%s)", pingReq.SenderId)
-			return
-		}
+// Applies a single membership update and re-gossips it if it was news to this node
+func (n *Node) applyUpdate(update *serial.MembershipUpdate) {
+	if update.NodeId == n.NodeId {
+		n.refute(update)
+		return
+	}
 
-		// Build updates
-		entries := n.Queue.GetEntries(n.NodeId, 5)
-		updates := make([]*serial.MembershipUpdate, len(entries))
-		for i, entry := range entries {
-			updates[i] = entry.Update
+	prev, peer, changed := n.MemberTable.updatePeer(update, true)
+	if !changed {
+		// A repeated accusation from another node is an independent confirmation
+		if peer.State == Suspected && update.State == serial.State_SUSPECT && update.Incarnation == peer.Incarnation {
+			if n.MemberTable.ConfirmSuspicion(peer.PeerID, peer.Incarnation, update.From) {
+				n.enqueue(update)
+			}
 		}
+		return
+	}
 
-		ping := &serial.Ping{
-			SenderId:      n.NodeId,
-			SenderAddress: pingReq.RequestAddress,
-			TargetId:      pingReq.TargetId,
-			Updates:       updates,
-		}
-		err = n.sendPing(ping)
-		if err != nil {
-			stream.Write([]byte("Nack"))
-		} else {
-			ack := &serial.Ack{
-				Response:      "Ack",
-				SenderId:      n.NodeId,
-				SenderAddress: pingReq.RequestAddress,
-				Incarnation:   n.MemberTable.Members[n.NodeId].Incarnation,
-				TargetId:      ping.SenderId,
-				Updates:       updates,
-			}
+	// Re-gossip the news, keeping the original accuser of a suspicion
+	regossip := newUpdate(peer, serial.State(peer.State))
+	regossip.From = update.From
+	n.enqueue(regossip)
 
-			ackData, _ := proto.Marshal(ack)
-			stream.Write(ackData)
-		}
-	}()
+	n.peerChanged(prev, peer, update.From)
 }
 
-// Processes acknowledgment messages and updates member table with received updates
-func (n *Node) handleAck(ack *serial.Ack) error {
-	for _, update := range ack.Updates {
-		if update.NodeId == ack.SenderId {
-			n.MemberTable.UpdatePeer(update, true)
-		}
+// Reacts to a peer's state or incarnation changing in the member table
+func (n *Node) peerChanged(prev, peer Peer, from string) {
+	n.emit(newMemberEvent(prev, peer))
+
+	switch peer.State {
+	case Alive:
+		n.addProbeTarget(peer.PeerID)
+	case Suspected:
+		n.addProbeTarget(peer.PeerID)
+		n.startSuspicion(peer, from)
+	case Dead:
+		n.evictConn(peer.Address)
+	case Left:
+		log.Printf("Peer %s left the cluster", peer.PeerID)
+		n.evictConn(peer.Address)
+	}
+}
+
+// Releases any transport state held for a peer that has been marked Dead or Left
+func (n *Node) evictConn(addr string) {
+	if forgetter, ok := n.Transport.(PeerForgetter); ok && addr != "" {
+		forgetter.Forget(addr)
 	}
-	return nil
 }
 
-// Processes negative acknowledgments and updates node states for failure detection
+// Processes negative acknowledgments by suspecting the peer and gossiping the suspicion
 func (n *Node) handleNack(id string) error {
 	n.MemberTable.mu.Lock()
-	defer n.MemberTable.mu.Unlock()
 
 	peer := n.MemberTable.Members[id]
 	if peer == nil {
+		n.MemberTable.mu.Unlock()
 		return fmt.Errorf("peer %s not found", id)
 	}
 
-	switch peer.State {
-	case Alive:
-		peer.State = Suspected
-	case Suspected:
-		if time.Since(peer.SinceStateUpdate) > PeerTTL {
-			peer.State = Dead
+	if peer.State == Suspected {
+		// Our own failed probe of a peer someone else suspects is an independent confirmation
+		suspected := *peer
+		n.MemberTable.mu.Unlock()
+		if n.MemberTable.ConfirmSuspicion(suspected.PeerID, suspected.Incarnation, n.NodeId) {
+			n.enqueueUpdate(suspected, serial.State_SUSPECT)
 		}
+		return nil
 	}
+	if peer.State != Alive {
+		n.MemberTable.mu.Unlock()
+		return nil
+	}
+	prev := *peer
+	peer.State = Suspected
+	peer.SinceStateUpdate = n.MemberTable.getClock().Now()
+	suspected := *peer
+	n.MemberTable.mu.Unlock()
 
+	n.emit(newMemberEvent(prev, suspected))
+	n.enqueueUpdate(suspected, serial.State_SUSPECT)
+	n.startSuspicion(suspected, n.NodeId)
 	return nil
 }
+
+// Queues a membership update about the peer for piggybacked dissemination, originating at this node
+func (n *Node) enqueueUpdate(peer Peer, state serial.State) {
+	update := newUpdate(peer, state)
+	update.From = n.NodeId
+	n.enqueue(update)
+}
+
+// Queues a membership update for piggybacked dissemination
+func (n *Node) enqueue(update *serial.MembershipUpdate) {
+	n.Queue.AddEntry(&Entry{
+		Update:    update,
+		Expiry:    n.getClock().Now().Add(PeerTTL),
+		SeenPeers: make(map[string]bool),
+	})
+}
+
+// Builds a membership update announcing the peer in the given state
+func newUpdate(peer Peer, state serial.State) *serial.MembershipUpdate {
+	return &serial.MembershipUpdate{
+		NodeId:      peer.PeerID,
+		Address:     peer.Address,
+		Incarnation: peer.Incarnation,
+		State:       state,
+		Meta:        peer.Meta,
+	}
+}
@@ -1,12 +1,23 @@
 package swim
 
 import (
+	"math"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/jscottransom/fringe/internal/clock"
 	serial "github.com/jscottransom/fringe/internal/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
 )
 
-const maxDelivery = 3
+const (
+	// maxDelivery is the retransmit limit used when the queue does not know the cluster size
+	maxDelivery = 3
+	// defaultRetransmitMult is the retransmit multiplier used when RetransmitMult is unset
+	defaultRetransmitMult = 4
+)
 
 // Entry represents a membership update with delivery tracking
 type Entry struct {
@@ -16,31 +27,54 @@ type Entry struct {
 	SeenPeers     map[string]bool
 }
 
-// PiggyBackQueue manages membership updates for efficient network propagation
+// PiggyBackQueue manages membership updates for efficient network propagation.
+// It holds at most one update per node and hands out the least transmitted updates first.
+// It is safe for concurrent use; Entries must only be read directly when no other goroutine uses the queue.
 type PiggyBackQueue struct {
 	Entries  []*Entry
 	Capacity int
+	Clock    clock.Clock
+
+	// Each update is sent RetransmitMult·ceil(log10(n+1)) times, where NumNodes reports the live cluster size n
+	RetransmitMult int
+	NumNodes       func() int
+
+	mu sync.Mutex
 }
 
-// Adds a new entry to the front of the queue with duplicate detection and capacity management
+// Adds a new entry to the front of the queue, replacing any older update about the same node.
+// The entry is dropped if the queue already holds a newer update about that node.
+// When the queue is full the most transmitted entry makes room.
 func (p *PiggyBackQueue) AddEntry(entry *Entry) {
-	// Check for duplicates
-	for _, dupe := range p.Entries {
-		if entry == dupe {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, existing := range p.Entries {
+		if entry == existing {
+			return
+		}
+		if existing.Update.NodeId != entry.Update.NodeId {
+			continue
+		}
+		if supersedes(existing.Update, entry.Update) {
 			return
 		}
+		p.Entries = append(p.Entries[:i], p.Entries[i+1:]...)
+		break
 	}
 
-	// Add to front, remove oldest if at capacity
-	if len(p.Entries) >= p.Capacity {
-		p.Entries = p.Entries[:len(p.Entries)-1]
+	if len(p.Entries) >= p.Capacity && len(p.Entries) > 0 {
+		p.removeMostTransmitted()
 	}
 	p.Entries = append([]*Entry{entry}, p.Entries...)
 }
 
 // Removes expired entries from the queue based on TTL expiration
 func (p *PiggyBackQueue) EvictEntry() {
-	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.getClock().Now()
 	for i := len(p.Entries) - 1; i >= 0; i-- {
 		if now.After(p.Entries[i].Expiry) {
 			p.Entries = append(p.Entries[:i], p.Entries[i+1:]...)
@@ -48,16 +82,111 @@ func (p *PiggyBackQueue) EvictEntry() {
 	}
 }
 
-// Returns entries that haven't been seen by the specified node and haven't exceeded delivery limit
+// Returns up to max entries the specified node hasn't seen, least transmitted first
 func (p *PiggyBackQueue) GetEntries(nodeID string, max int) []*Entry {
+	count := 0
+	return p.take(nodeID, func(*Entry) bool {
+		if count >= max {
+			return false
+		}
+		count++
+		return true
+	})
+}
+
+// Returns the entries the specified node hasn't seen, least transmitted first, whose encoded
+// updates fit within budget bytes of a message
+func (p *PiggyBackQueue) GetEntriesWithin(nodeID string, budget int) []*Entry {
+	used := 0
+	return p.take(nodeID, func(entry *Entry) bool {
+		size := encodedSize(entry.Update)
+		if used+size > budget {
+			return false
+		}
+		used += size
+		return true
+	})
+}
+
+// Returns how many times each update is sent, growing with the log of the live cluster size
+// so updates still reach every member with high probability as the cluster grows
+func (p *PiggyBackQueue) RetransmitLimit() int {
+	if p.NumNodes == nil {
+		return maxDelivery
+	}
+	mult := p.RetransmitMult
+	if mult <= 0 {
+		mult = defaultRetransmitMult
+	}
+	n := max(1, p.NumNodes())
+	return mult * int(math.Ceil(math.Log10(float64(n+1))))
+}
+
+// Hands out eligible entries in priority order while accept admits them, counting the delivery
+// and dropping entries that reached the retransmit limit
+func (p *PiggyBackQueue) take(nodeID string, accept func(*Entry) bool) []*Entry {
+	limit := p.RetransmitLimit()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Entries are kept newest first, so a stable sort breaks ties in favor of fresher news
+	ordered := make([]*Entry, len(p.Entries))
+	copy(ordered, p.Entries)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].DeliveryCount < ordered[j].DeliveryCount
+	})
+
 	var entries []*Entry
-	for i := 0; i < len(p.Entries) && i < max; i++ {
-		entry := p.Entries[i]
-		if !entry.SeenPeers[nodeID] && entry.DeliveryCount < maxDelivery {
-			entries = append(entries, entry)
-			entry.DeliveryCount++
-			entry.SeenPeers[nodeID] = true
+	for _, entry := range ordered {
+		if entry.SeenPeers[nodeID] || entry.DeliveryCount >= limit || !accept(entry) {
+			continue
+		}
+		entries = append(entries, entry)
+		entry.DeliveryCount++
+		entry.SeenPeers[nodeID] = true
+	}
+
+	kept := p.Entries[:0]
+	for _, entry := range p.Entries {
+		if entry.DeliveryCount < limit {
+			kept = append(kept, entry)
 		}
 	}
+	clear(p.Entries[len(kept):])
+	p.Entries = kept
 	return entries
 }
+
+// Removes the entry sent the most times, preferring the oldest; the caller must hold the lock
+func (p *PiggyBackQueue) removeMostTransmitted() {
+	idx := len(p.Entries) - 1
+	for i := len(p.Entries) - 1; i >= 0; i-- {
+		if p.Entries[i].DeliveryCount > p.Entries[idx].DeliveryCount {
+			idx = i
+		}
+	}
+	p.Entries = append(p.Entries[:idx], p.Entries[idx+1:]...)
+}
+
+// Reports whether the current update should be kept over the candidate: it has a higher
+// incarnation, or the same incarnation with a state of higher precedence
+func supersedes(current, candidate *serial.MembershipUpdate) bool {
+	if current.Incarnation != candidate.Incarnation {
+		return current.Incarnation > candidate.Incarnation
+	}
+	return current.State > candidate.State
+}
+
+// Returns the bytes a piggybacked message adds to its carrier as a repeated field
+func encodedSize(msg proto.Message) int {
+	return protowire.SizeTag(1) + protowire.SizeBytes(proto.Size(msg))
+}
+
+// Returns the queue's clock, defaulting to wall clock time
+func (p *PiggyBackQueue) getClock() clock.Clock {
+	if p.Clock == nil {
+		return clock.Real{}
+	}
+	return p.Clock
+}
@@ -0,0 +1,146 @@
+package swim
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// pooledConn holds the current QUIC connection to a single peer
+type pooledConn struct {
+	conn *quic.Conn
+	mu   sync.Mutex
+}
+
+// connPool keeps one QUIC connection per peer address and opens a new stream for each exchange
+type connPool struct {
+	transport *quic.Transport
+	tlsConf   *tls.Config
+	conns     map[string]*pooledConn
+	mu        sync.Mutex
+}
+
+// Creates a connection pool that dials peers over the given QUIC transport
+func newConnPool(tr *quic.Transport) *connPool {
+	return &connPool{
+		transport: tr,
+		tlsConf: &tls.Config{
+			InsecureSkipVerify: true,
+			NextProtos:         []string{alpnProtocol},
+		},
+		conns: make(map[string]*pooledConn),
+	}
+}
+
+// Opens a stream to the peer, redialing once if the pooled connection has gone idle or failed
+func (p *connPool) openStream(ctx context.Context, addr string) (*quic.Stream, error) {
+	conn, err := p.get(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := conn.OpenStreamSync(ctx)
+	if err == nil {
+		return stream, nil
+	}
+
+	p.discard(addr, conn)
+	conn, err = p.get(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err = conn.OpenStreamSync(ctx)
+	if err != nil {
+		p.discard(addr, conn)
+		return nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+	return stream, nil
+}
+
+// Returns the live connection to the peer, dialing a new one if none is usable
+func (p *connPool) get(ctx context.Context, addr string) (*quic.Conn, error) {
+	p.mu.Lock()
+	pc, ok := p.conns[addr]
+	if !ok {
+		pc = &pooledConn{}
+		p.conns[addr] = pc
+	}
+	p.mu.Unlock()
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.conn != nil && pc.conn.Context().Err() == nil {
+		return pc.conn, nil
+	}
+
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve address: %w", err)
+	}
+
+	conn, err := p.transport.Dial(ctx, udpAddr, p.tlsConf, quicConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial QUIC connection: %w", err)
+	}
+	pc.conn = conn
+	return conn, nil
+}
+
+// Closes the given connection and forgets it if it is still the pooled one for the peer
+func (p *connPool) discard(addr string, conn *quic.Conn) {
+	p.mu.Lock()
+	pc, ok := p.conns[addr]
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	pc.mu.Lock()
+	if pc.conn == conn {
+		pc.conn = nil
+	}
+	pc.mu.Unlock()
+
+	conn.CloseWithError(quic.ApplicationErrorCode(0), "")
+}
+
+// Closes and removes the pooled connection to a peer that is no longer a member
+func (p *connPool) evict(addr string) {
+	p.mu.Lock()
+	pc, ok := p.conns[addr]
+	delete(p.conns, addr)
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.conn != nil {
+		pc.conn.CloseWithError(quic.ApplicationErrorCode(0), "peer removed")
+		pc.conn = nil
+	}
+}
+
+// Closes every pooled connection
+func (p *connPool) close() {
+	p.mu.Lock()
+	conns := p.conns
+	p.conns = make(map[string]*pooledConn)
+	p.mu.Unlock()
+
+	for _, pc := range conns {
+		pc.mu.Lock()
+		if pc.conn != nil {
+			pc.conn.CloseWithError(quic.ApplicationErrorCode(0), "")
+			pc.conn = nil
+		}
+		pc.mu.Unlock()
+	}
+}
@@ -0,0 +1,78 @@
+package swim
+
+// Returns the next peer to probe, walking a shuffled member list and reshuffling after each
+// full pass so every member is probed within a bounded number of protocol periods
+func (n *Node) nextProbeTarget() (Peer, bool) {
+	n.probeMu.Lock()
+	defer n.probeMu.Unlock()
+
+	reshuffled := false
+	for {
+		if n.probeIndex >= len(n.probeOrder) {
+			if reshuffled {
+				return Peer{}, false
+			}
+			n.resetProbeOrder()
+			reshuffled = true
+			continue
+		}
+
+		id := n.probeOrder[n.probeIndex]
+		n.probeIndex++
+
+		peer, ok := n.MemberTable.GetPeer(id)
+		if ok && peer.PeerID != n.NodeId && (peer.State == Alive || peer.State == Suspected) {
+			return peer, true
+		}
+	}
+}
+
+// Rebuilds the probe order from the live members in random order; the caller must hold probeMu
+func (n *Node) resetProbeOrder() {
+	order := n.probeOrder[:0]
+	for _, peer := range n.MemberTable.Snapshot() {
+		if peer.PeerID != n.NodeId && (peer.State == Alive || peer.State == Suspected) {
+			order = append(order, peer.PeerID)
+		}
+	}
+	n.shuffle(len(order), func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
+	n.probeOrder = order
+	n.probeIndex = 0
+}
+
+// Inserts a newly learned member at a random position in the probe order
+func (n *Node) addProbeTarget(nodeID string) {
+	if nodeID == n.NodeId {
+		return
+	}
+
+	n.probeMu.Lock()
+	defer n.probeMu.Unlock()
+
+	for _, id := range n.probeOrder {
+		if id == nodeID {
+			return
+		}
+	}
+
+	pos := n.randIntn(len(n.probeOrder) + 1)
+	n.probeOrder = append(n.probeOrder, "")
+	copy(n.probeOrder[pos+1:], n.probeOrder[pos:])
+	n.probeOrder[pos] = nodeID
+	// Inserting behind the cursor must not shift the walk back onto an already probed member
+	if pos < n.probeIndex {
+		n.probeIndex++
+	}
+}
+
+// Returns the current randomized round-robin probe order and the index of the next target, for debugging
+func (n *Node) ProbeOrder() ([]string, int) {
+	n.probeMu.Lock()
+	defer n.probeMu.Unlock()
+
+	order := make([]string, len(n.probeOrder))
+	copy(order, n.probeOrder)
+	return order, n.probeIndex
+}
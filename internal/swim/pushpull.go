@@ -0,0 +1,130 @@
+package swim
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/jscottransom/fringe/internal/clock"
+	serial "github.com/jscottransom/fringe/internal/proto"
+)
+
+// One push-pull round in this many goes to a member marked Dead or a join address instead of an alive peer
+const reconnectOdds = 3
+
+// Exchanges full state with a random peer once per push-pull interval so partitions heal
+func (n *Node) periodicPushPull() {
+	n.every(func() time.Duration {
+		return n.Config.withDefaults().PushPullInterval
+	}, n.pushPullRandomPeer)
+}
+
+// Picks a random alive peer and exchanges full state with it. Now and then, or whenever no peer is
+// alive, it picks a member marked Dead or an address this node joined through instead, since alive
+// peers alone never reach the other side of a partition once both sides have declared each other dead.
+func (n *Node) pushPullRandomPeer() {
+	var candidates []string
+	for _, peer := range n.MemberTable.GetAlivePeers() {
+		if peer.PeerID != n.NodeId {
+			candidates = append(candidates, peer.Address)
+		}
+	}
+	if reconnect := n.reconnectTargets(); len(reconnect) > 0 && (len(candidates) == 0 || n.randIntn(reconnectOdds) == 0) {
+		candidates = reconnect
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	addr := candidates[n.randIntn(len(candidates))]
+
+	ctx, cancel := clock.WithTimeout(n.ctx, n.getClock(), messageTimeout)
+	defer cancel()
+
+	if err := n.sendPushPull(ctx, addr, false); err != nil {
+		log.Printf("Push-pull with %s failed: %v", addr, err)
+	}
+}
+
+// Returns the addresses of members marked Dead, which may only have been cut off, and of join
+// addresses that no live member holds; members that left are not contacted again
+func (n *Node) reconnectTargets() []string {
+	live := make(map[string]bool)
+	var targets []string
+	for _, peer := range n.MemberTable.Snapshot() {
+		switch peer.State {
+		case Alive, Suspected:
+			live[peer.Address] = true
+		case Dead:
+			if peer.PeerID != n.NodeId {
+				targets = append(targets, peer.Address)
+			}
+		}
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for _, seed := range n.seeds {
+		if !live[seed] && !slices.Contains(targets, seed) {
+			targets = append(targets, seed)
+		}
+	}
+	return targets
+}
+
+// Sends the entire member table to the peer and merges the peer's table from the reply
+func (n *Node) sendPushPull(ctx context.Context, addr string, join bool) error {
+	pushPull := n.buildPushPull(join)
+	reply, err := n.exchange(ctx, addr, &serial.Envelope{Msg: &serial.Envelope_PushPull{PushPull: pushPull}})
+	if err != nil {
+		return err
+	}
+
+	remote := reply.GetPushPull()
+	if remote == nil {
+		return fmt.Errorf("no push-pull reply from %s", addr)
+	}
+	n.mergeState(remote.States)
+	return nil
+}
+
+// Processes an incoming push-pull by replying with local state and merging the remote state
+func (n *Node) handlePushPull(pushPull *serial.PushPull) *serial.PushPull {
+	if pushPull.SenderId == n.NodeId {
+		log.Printf("ignoring push-pull from self (%s)", pushPull.SenderId)
+		return nil
+	}
+
+	// Capture local state before merging so the reply reflects what this node knew
+	reply := n.buildPushPull(false)
+	n.mergeState(pushPull.States)
+
+	if pushPull.Join {
+		log.Printf("Exchanged full state with joining node %s", pushPull.SenderId)
+	}
+	return reply
+}
+
+// Builds a push-pull message carrying every entry of the member table
+func (n *Node) buildPushPull(join bool) *serial.PushPull {
+	peers := n.MemberTable.Snapshot()
+	states := make([]*serial.MembershipUpdate, len(peers))
+	for i, peer := range peers {
+		states[i] = newUpdate(peer, serial.State(peer.State))
+	}
+
+	return &serial.PushPull{
+		SenderId:      n.NodeId,
+		SenderAddress: n.Addr,
+		Join:          join,
+		States:        states,
+	}
+}
+
+// Merges a remote member table into the local one
+func (n *Node) mergeState(states []*serial.MembershipUpdate) {
+	for _, update := range states {
+		n.applyUpdate(update)
+	}
+}
@@ -0,0 +1,247 @@
+package swim
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	serial "github.com/jscottransom/fringe/internal/proto"
+	quic "github.com/quic-go/quic-go"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	alpnProtocol = "quic"
+	idleTimeout  = 30 * time.Second
+)
+
+// QUICTransport exchanges envelopes over QUIC, one stream per exchange on a pooled connection per peer
+type QUICTransport struct {
+	transport *quic.Transport
+	listener  *quic.Listener
+	pool      *connPool
+	incoming  chan *Message
+	accepted  atomic.Int64
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+// Returns the QUIC configuration shared by the listener and pooled dials; idle connections are closed and redialed on demand
+func quicConfig() *quic.Config {
+	return &quic.Config{
+		HandshakeIdleTimeout: 30 * time.Second,
+		MaxIdleTimeout:       idleTimeout,
+	}
+}
+
+// Creates a QUIC transport that listens and dials on the given socket
+func NewQUICTransport(conn net.PacketConn) (*QUICTransport, error) {
+	tlsConf, err := generateTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TLS config: %w", err)
+	}
+
+	tr := &quic.Transport{Conn: conn}
+	listener, err := tr.Listen(tlsConf, quicConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen QUIC: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &QUICTransport{
+		transport: tr,
+		listener:  listener,
+		pool:      newConnPool(tr),
+		incoming:  make(chan *Message),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	go t.acceptLoop()
+
+	log.Printf("QUIC transport listening on %s", conn.LocalAddr())
+	return t, nil
+}
+
+// Sends an envelope on a new stream of the pooled connection and returns the peer's reply envelope
+func (t *QUICTransport) Send(ctx context.Context, addr string, msg *serial.Envelope) (*serial.Envelope, error) {
+	if t.ctx.Err() != nil {
+		return nil, ErrTransportClosed
+	}
+
+	stream, err := t.pool.openStream(ctx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stream to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetDeadline(deadline)
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		stream.CancelWrite(0)
+		stream.CancelRead(0)
+		return nil, fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+
+	// Stream errors are per exchange; a dead connection is redialed by the pool on next use
+	if _, err := stream.Write(data); err != nil {
+		stream.CancelRead(0)
+		return nil, fmt.Errorf("failed to write to %s: %w", addr, err)
+	}
+	// Closing the send side signals the end of the request to the peer
+	if err := stream.Close(); err != nil {
+		stream.CancelRead(0)
+		return nil, fmt.Errorf("failed to close stream to %s: %w", addr, err)
+	}
+
+	resp, err := io.ReadAll(io.LimitReader(stream, maxMessageSize))
+	if err != nil {
+		stream.CancelRead(0)
+		return nil, fmt.Errorf("failed to read response from %s: %w", addr, err)
+	}
+
+	var reply serial.Envelope
+	if err := proto.Unmarshal(resp, &reply); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response from %s: %w", addr, err)
+	}
+	return &reply, nil
+}
+
+// Returns the channel of incoming messages
+func (t *QUICTransport) Receive() <-chan *Message {
+	return t.incoming
+}
+
+// Closes the listener and every pooled connection
+func (t *QUICTransport) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		t.cancel()
+		err = t.listener.Close()
+		t.pool.close()
+	})
+	return err
+}
+
+// Drops the pooled connection to a peer that is no longer a member
+func (t *QUICTransport) Forget(addr string) {
+	t.pool.evict(addr)
+}
+
+// Accepts incoming QUIC connections until the transport is closed
+func (t *QUICTransport) acceptLoop() {
+	for {
+		sess, err := t.listener.Accept(t.ctx)
+		if err != nil {
+			if t.ctx.Err() == nil {
+				log.Printf("failed to accept connection: %v", err)
+			}
+			return
+		}
+		t.accepted.Add(1)
+		go t.handleConn(sess)
+	}
+}
+
+// Returns how many incoming connections the listener has accepted
+func (t *QUICTransport) Accepted() int64 {
+	return t.accepted.Load()
+}
+
+// Accepts streams on an incoming connection, one envelope exchange per stream
+func (t *QUICTransport) handleConn(sess *quic.Conn) {
+	for {
+		stream, err := sess.AcceptStream(t.ctx)
+		if err != nil {
+			return
+		}
+		go t.handleStream(stream, sess.RemoteAddr().String())
+	}
+}
+
+// Decodes an envelope from the stream, hands it to the node and writes back its reply
+func (t *QUICTransport) handleStream(stream *quic.Stream, from string) {
+	defer stream.Close()
+
+	stream.SetDeadline(time.Now().Add(messageTimeout))
+
+	data, err := io.ReadAll(io.LimitReader(stream, maxMessageSize))
+	if err != nil {
+		log.Printf("failed to read stream: %v", err)
+		return
+	}
+
+	var env serial.Envelope
+	if err := proto.Unmarshal(data, &env); err != nil {
+		log.Printf("failed to Unmarshal Envelope: %v", err)
+		return
+	}
+
+	done := make(chan struct{})
+	msg := NewMessage(&env, from, func(reply *serial.Envelope) error {
+		defer close(done)
+		if reply == nil {
+			return nil
+		}
+		replyData, err := proto.Marshal(reply)
+		if err != nil {
+			return fmt.Errorf("failed to marshal reply: %w", err)
+		}
+		if _, err := stream.Write(replyData); err != nil {
+			return fmt.Errorf("failed to write reply to stream: %w", err)
+		}
+		return nil
+	})
+
+	select {
+	case t.incoming <- msg:
+	case <-t.ctx.Done():
+		return
+	}
+
+	select {
+	case <-done:
+	case <-stream.Context().Done():
+	case <-t.ctx.Done():
+	}
+}
+
+// Generates a self-signed certificate for the QUIC listener
+func generateTLSConfig() (*tls.Config, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fringe"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{certDER},
+			PrivateKey:  key,
+		}},
+		NextProtos: []string{alpnProtocol},
+	}, nil
+}
@@ -0,0 +1,106 @@
+package swim
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	serial "github.com/jscottransom/fringe/internal/proto"
+)
+
+const (
+	messageTimeout = 3 * time.Second
+	maxMessageSize = 1 << 20
+
+	ackResponse  = "Ack"
+	nackResponse = "Nack"
+)
+
+// Dispatches messages arriving on the node's transport until the context is cancelled, then closes the transport
+func (n *Node) Serve(ctx context.Context) error {
+	if n.Transport == nil {
+		return fmt.Errorf("node %s has no transport", n.NodeId)
+	}
+	defer n.Transport.Close()
+
+	incoming := n.Transport.Receive()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-incoming:
+			if !ok {
+				return nil
+			}
+			if n.serialTransport() {
+				n.handleMessage(msg)
+				continue
+			}
+			go n.handleMessage(msg)
+		}
+	}
+}
+
+// Reports whether the node's transport carries one exchange at a time
+func (n *Node) serialTransport() bool {
+	st, ok := n.Transport.(SerialTransport)
+	return ok && st.Serial()
+}
+
+// Routes an incoming message to its handler and answers it with any reply
+func (n *Node) handleMessage(msg *Message) {
+	reply := n.dispatch(msg.Envelope)
+	if err := msg.Reply(reply); err != nil {
+		log.Printf("failed to reply to %s: %v", msg.From, err)
+	}
+}
+
+// Routes an envelope to the Ping, PingReq, PushPull or Ack handler, or to the registered message
+// handler for other types, and returns the reply envelope, if any
+func (n *Node) dispatch(env *serial.Envelope) *serial.Envelope {
+	switch msg := env.Msg.(type) {
+	case *serial.Envelope_Ping:
+		messageCounter.WithLabelValues("ping").Inc()
+		if ack := n.handlePing(msg.Ping); ack != nil {
+			return &serial.Envelope{Msg: &serial.Envelope_Ack{Ack: ack}}
+		}
+	case *serial.Envelope_PingReq:
+		messageCounter.WithLabelValues("ping_req").Inc()
+		if ack := n.handlePingReq(msg.PingReq); ack != nil {
+			return &serial.Envelope{Msg: &serial.Envelope_Ack{Ack: ack}}
+		}
+	case *serial.Envelope_PushPull:
+		messageCounter.WithLabelValues("push_pull").Inc()
+		if pushPull := n.handlePushPull(msg.PushPull); pushPull != nil {
+			return &serial.Envelope{Msg: &serial.Envelope_PushPull{PushPull: pushPull}}
+		}
+	case *serial.Envelope_Ack:
+		messageCounter.WithLabelValues("ack").Inc()
+		if err := n.handleAck(msg.Ack); err != nil {
+			log.Printf("failed to handle ack: %v", err)
+		}
+	default:
+		n.mu.RLock()
+		handler := n.messageHandler
+		n.mu.RUnlock()
+		if handler != nil {
+			return handler(env)
+		}
+		log.Printf("ignoring envelope with unknown message type %T", msg)
+	}
+	return nil
+}
+
+// Sets the function that answers envelopes the membership protocol does not handle itself,
+// letting services such as anti-entropy sync share the node's transport
+func (n *Node) HandleMessages(handler func(*serial.Envelope) *serial.Envelope) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messageHandler = handler
+}
+
+// Sends an envelope to the address over the node's transport and returns the reply
+func (n *Node) Exchange(ctx context.Context, addr string, env *serial.Envelope) (*serial.Envelope, error) {
+	return n.exchange(ctx, addr, env)
+}
@@ -0,0 +1,182 @@
+package swim
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/jscottransom/fringe/internal/clock"
+	serial "github.com/jscottransom/fringe/internal/proto"
+)
+
+// suspicion tracks the timer that declares a suspected peer Dead.
+// With Lifeguard's dynamic suspicion the timeout starts at max and shrinks towards min
+// as k independent confirmations arrive.
+type suspicion struct {
+	timer         clock.Timer
+	incarnation   uint64
+	start         time.Time
+	min           time.Duration
+	max           time.Duration
+	k             int
+	confirmations map[string]bool
+}
+
+// Returns the suspicion timeout after the given number of confirmations
+func (s *suspicion) timeout(confirmations int) time.Duration {
+	if s.k < 1 || s.max <= s.min {
+		return s.min
+	}
+	frac := math.Log(float64(confirmations)+1) / math.Log(float64(s.k)+1)
+	timeout := s.max - time.Duration(frac*float64(s.max-s.min))
+	if timeout < s.min {
+		timeout = s.min
+	}
+	return timeout
+}
+
+// Starts a suspicion timer for the peer that marks it Dead on expiry unless it is refuted first.
+// Any earlier timer for the peer is replaced.
+func (n *NodeTable) StartSuspicion(nodeID string, incarnation uint64, timeout time.Duration, onDead func(Peer)) {
+	n.StartDynamicSuspicion(nodeID, incarnation, "", timeout, timeout, 0, onDead)
+}
+
+// Starts a suspicion timer accused by from whose timeout shrinks from max to min as k
+// independent confirmations arrive through ConfirmSuspicion
+func (n *NodeTable) StartDynamicSuspicion(nodeID string, incarnation uint64, from string, minTimeout, maxTimeout time.Duration, k int, onDead func(Peer)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.suspicions == nil {
+		n.suspicions = make(map[string]*suspicion)
+	}
+	n.stopSuspicion(nodeID)
+
+	s := &suspicion{
+		incarnation:   incarnation,
+		start:         n.getClock().Now(),
+		min:           minTimeout,
+		max:           maxTimeout,
+		k:             k,
+		confirmations: map[string]bool{from: true},
+	}
+	s.timer = n.getClock().AfterFunc(s.timeout(0), func() {
+		if dead, ok := n.expireSuspicion(nodeID, s); ok && onDead != nil {
+			onDead(dead)
+		}
+	})
+	n.suspicions[nodeID] = s
+}
+
+// Records an independent confirmation of the peer's suspicion and shortens its timer.
+// Returns false if the confirmation was a duplicate or not needed.
+func (n *NodeTable) ConfirmSuspicion(nodeID string, incarnation uint64, from string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	s, ok := n.suspicions[nodeID]
+	if !ok || s.incarnation != incarnation || from == "" || s.confirmations[from] {
+		return false
+	}
+	// The accuser is counted in confirmations but is not itself a confirmation
+	if len(s.confirmations)-1 >= s.k {
+		return false
+	}
+	s.confirmations[from] = true
+
+	remaining := s.timeout(len(s.confirmations)-1) - n.getClock().Since(s.start)
+	s.timer.Reset(max(0, remaining))
+	return true
+}
+
+// Cancels the suspicion timer for the peer, if any
+func (n *NodeTable) StopSuspicion(nodeID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.stopSuspicion(nodeID)
+}
+
+// Cancels the suspicion timer for the peer; the caller must hold the table lock
+func (n *NodeTable) stopSuspicion(nodeID string) {
+	if s, ok := n.suspicions[nodeID]; ok {
+		s.timer.Stop()
+		delete(n.suspicions, nodeID)
+	}
+}
+
+// Marks the peer Dead if the expired timer is still current and the suspicion was not refuted
+func (n *NodeTable) expireSuspicion(nodeID string, s *suspicion) (Peer, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.suspicions[nodeID] != s {
+		return Peer{}, false
+	}
+	delete(n.suspicions, nodeID)
+
+	peer := n.Members[nodeID]
+	if peer == nil || peer.State != Suspected || peer.Incarnation != s.incarnation {
+		return Peer{}, false
+	}
+	peer.State = Dead
+	peer.SinceStateUpdate = n.getClock().Now()
+	return *peer, true
+}
+
+// Returns how long a peer may stay Suspected, scaled by log10 of the cluster size as in the SWIM paper
+func (n *Node) suspicionTimeout() time.Duration {
+	cfg := n.Config.withDefaults()
+	size := max(1, n.MemberTable.GetClusterSize())
+	scale := math.Max(1, math.Log10(float64(size)))
+	return time.Duration(float64(cfg.SuspicionMult) * scale * float64(cfg.ProbeInterval))
+}
+
+// Starts the suspicion timer for a peer that was just accused by from
+func (n *Node) startSuspicion(peer Peer, from string) {
+	cfg := n.Config.withDefaults()
+	minTimeout := n.suspicionTimeout()
+	if !cfg.DynamicSuspicion {
+		n.MemberTable.StartSuspicion(peer.PeerID, peer.Incarnation, minTimeout, n.declareDead)
+		return
+	}
+
+	// Expect a confirmation from each indirect helper, excluding ourselves and the suspect
+	k := min(cfg.IndirectChecks, n.MemberTable.GetClusterSize()-2)
+	maxTimeout := minTimeout * time.Duration(cfg.SuspicionMaxTimeoutMult)
+	n.MemberTable.StartDynamicSuspicion(peer.PeerID, peer.Incarnation, from, minTimeout, maxTimeout, k, n.declareDead)
+}
+
+// Gossips the death of a peer whose suspicion timer expired
+func (n *Node) declareDead(peer Peer) {
+	log.Printf("Suspicion of %s expired, marking dead", peer.PeerID)
+	n.emit(MemberEvent{Type: EventDead, Peer: peer, OldState: Suspected, NewState: Dead})
+	n.enqueueUpdate(peer, serial.State_DEAD)
+	n.evictConn(peer.Address)
+}
+
+// Overrides a SUSPECT or DEAD rumor about this node by bumping its incarnation and gossiping ALIVE
+func (n *Node) refute(update *serial.MembershipUpdate) {
+	if update.State != serial.State_SUSPECT && update.State != serial.State_DEAD {
+		return
+	}
+
+	n.MemberTable.mu.Lock()
+	self := n.MemberTable.Members[n.NodeId]
+	if self == nil || self.State == Left || self.Incarnation > update.Incarnation {
+		n.MemberTable.mu.Unlock()
+		return
+	}
+	prev := *self
+	self.Incarnation = update.Incarnation + 1
+	self.State = Alive
+	refuted := *self
+	n.MemberTable.mu.Unlock()
+
+	n.emit(MemberEvent{Type: EventRefute, Peer: refuted, OldState: prev.State, NewState: refuted.State})
+
+	log.Printf("Refuting %s rumor about self, incarnation now %d", update.State, refuted.Incarnation)
+	messageCounter.WithLabelValues("refute").Inc()
+	// Being accused means peers are not hearing from us in time
+	n.adjustHealth(1)
+	n.enqueueUpdate(refuted, serial.State_ALIVE)
+}
@@ -0,0 +1,65 @@
+package swim
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	serial "github.com/jscottransom/fringe/internal/proto"
+)
+
+// ErrTransportClosed is returned when sending on or through a closed transport
+var ErrTransportClosed = errors.New("transport closed")
+
+// Transport carries envelopes between SWIM nodes. Every exchange is a request that the
+// receiving node answers with at most one reply envelope.
+type Transport interface {
+	// Sends msg to addr and waits for the peer's reply until the context expires
+	Send(ctx context.Context, addr string, msg *serial.Envelope) (*serial.Envelope, error)
+	// Returns the channel of incoming messages, closed when the transport closes
+	Receive() <-chan *Message
+	// Stops accepting messages and releases the transport's resources
+	Close() error
+}
+
+// PeerForgetter is implemented by transports that keep per-peer state, such as pooled
+// connections, which should be released once a peer is Dead or Left
+type PeerForgetter interface {
+	Forget(addr string)
+}
+
+// SerialTransport is implemented by transports that carry one exchange at a time, such as a
+// simulated network. Nodes on such a transport send fan-out requests one after another and handle
+// incoming messages in arrival order, so that runs are reproducible.
+type SerialTransport interface {
+	Serial() bool
+}
+
+// Message is an incoming envelope together with a way to answer it
+type Message struct {
+	Envelope *serial.Envelope
+	From     string
+	reply    func(*serial.Envelope) error
+	once     sync.Once
+}
+
+// Creates an incoming message whose reply is delivered through the given function
+func NewMessage(env *serial.Envelope, from string, reply func(*serial.Envelope) error) *Message {
+	return &Message{
+		Envelope: env,
+		From:     from,
+		reply:    reply,
+	}
+}
+
+// Answers the message; a nil envelope completes the exchange without a reply.
+// Only the first call has any effect.
+func (m *Message) Reply(env *serial.Envelope) error {
+	var err error
+	m.once.Do(func() {
+		if m.reply != nil {
+			err = m.reply(env)
+		}
+	})
+	return err
+}
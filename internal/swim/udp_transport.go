@@ -0,0 +1,167 @@
+package swim
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	serial "github.com/jscottransom/fringe/internal/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// udpHeaderSize covers the sequence number and flags prefixed to every packet
+	udpHeaderSize = 5
+	maxPacketSize = 65507
+
+	udpFlagReply = 1
+)
+
+// UDPTransport exchanges envelopes as single UDP packets, matching replies to requests by sequence number.
+// Envelopes larger than one datagram, such as push-pull state of very large clusters, cannot be sent.
+type UDPTransport struct {
+	conn      net.PacketConn
+	incoming  chan *Message
+	pending   map[uint32]chan *serial.Envelope
+	seq       atomic.Uint32
+	mu        sync.Mutex
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Creates a UDP packet transport on the given socket
+func NewUDPTransport(conn net.PacketConn) *UDPTransport {
+	t := &UDPTransport{
+		conn:     conn,
+		incoming: make(chan *Message),
+		pending:  make(map[uint32]chan *serial.Envelope),
+		closed:   make(chan struct{}),
+	}
+	go t.readLoop()
+	return t
+}
+
+// Sends an envelope in one packet and waits for the matching reply packet
+func (t *UDPTransport) Send(ctx context.Context, addr string, msg *serial.Envelope) (*serial.Envelope, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve address: %w", err)
+	}
+
+	seq := t.seq.Add(1)
+	replies := make(chan *serial.Envelope, 1)
+	t.mu.Lock()
+	t.pending[seq] = replies
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, seq)
+		t.mu.Unlock()
+	}()
+
+	if err := t.writePacket(udpAddr, seq, 0, msg); err != nil {
+		return nil, fmt.Errorf("failed to write to %s: %w", addr, err)
+	}
+
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no reply from %s: %w", addr, ctx.Err())
+	case <-t.closed:
+		return nil, ErrTransportClosed
+	}
+}
+
+// Returns the channel of incoming messages
+func (t *UDPTransport) Receive() <-chan *Message {
+	return t.incoming
+}
+
+// Closes the socket and stops reading packets
+func (t *UDPTransport) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		close(t.closed)
+		err = t.conn.Close()
+	})
+	return err
+}
+
+// Frames and writes an envelope to the address
+func (t *UDPTransport) writePacket(addr net.Addr, seq uint32, flags byte, env *serial.Envelope) error {
+	data, err := proto.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+	if len(data)+udpHeaderSize > maxPacketSize {
+		return fmt.Errorf("envelope of %d bytes exceeds UDP packet size", len(data))
+	}
+
+	packet := make([]byte, udpHeaderSize, udpHeaderSize+len(data))
+	binary.BigEndian.PutUint32(packet, seq)
+	packet[4] = flags
+	packet = append(packet, data...)
+
+	_, err = t.conn.WriteTo(packet, addr)
+	return err
+}
+
+// Reads packets, routing replies to waiting senders and requests to the node
+func (t *UDPTransport) readLoop() {
+	defer close(t.incoming)
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := t.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-t.closed:
+			default:
+				log.Printf("failed to read UDP packet: %v", err)
+			}
+			return
+		}
+		if n < udpHeaderSize {
+			continue
+		}
+
+		seq := binary.BigEndian.Uint32(buf)
+		flags := buf[4]
+		var env serial.Envelope
+		if err := proto.Unmarshal(buf[udpHeaderSize:n], &env); err != nil {
+			log.Printf("failed to Unmarshal Envelope from %s: %v", addr, err)
+			continue
+		}
+
+		if flags&udpFlagReply != 0 {
+			t.mu.Lock()
+			replies, ok := t.pending[seq]
+			t.mu.Unlock()
+			if ok {
+				select {
+				case replies <- &env:
+				default:
+				}
+			}
+			continue
+		}
+
+		msg := NewMessage(&env, addr.String(), func(reply *serial.Envelope) error {
+			if reply == nil {
+				// Senders still wait for an answer, so complete the exchange with an empty envelope
+				reply = &serial.Envelope{}
+			}
+			return t.writePacket(addr, seq, udpFlagReply, reply)
+		})
+		select {
+		case t.incoming <- msg:
+		case <-t.closed:
+			return
+		}
+	}
+}
@@ -5,35 +5,78 @@ import (
 	"encoding/hex"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/jscottransom/fringe/internal/clock"
 )
 
+// Number of children of each interior node; each level consumes one nibble of the key's hash
+const fanout = 16
+
 // MerkleNode represents a node in the Merkle tree with hash and data
 type MerkleNode struct {
 	Hash     string
-	Left     *MerkleNode
-	Right    *MerkleNode
 	Data     []byte
 	IsLeaf   bool
 	Key      string
-	Modified time.Time
+	Modified time.Time // for tombstones, when the key was deleted
+	Deleted  bool
+	Siblings []DataItem    // concurrent writes to a leaf's key; Data and Deleted reflect the one reads see
+	Children []*MerkleNode // fanout slots, nil when empty, for interior nodes; leaves in key order for buckets
+	dirty    bool
+	acked    map[string]bool // peers known to hold this tombstone
+}
+
+// SubtreeHash is the hash of one node in the tree, addressed by the child indexes leading to it from the root
+type SubtreeHash struct {
+	Path    []int
+	Hash    string
+	IsLeaf  bool
+	Key     string
+	Deleted bool
 }
 
-// MerkleTree provides efficient data synchronization with hash-based diff detection
+// MerkleTree provides efficient data synchronization with hash-based diff detection. Keys are
+// bucketed by the prefix of their hash under a fixed-fanout tree MaxDepth levels deep, so every
+// tree of the same depth has the same shape, interior hashes can be compared across nodes, and a
+// write only rehashes its bucket and the nodes on the path to the root.
 type MerkleTree struct {
 	Root     *MerkleNode
 	Leaves   map[string]*MerkleNode
 	mu       sync.RWMutex
 	MaxDepth int
+	Clock    clock.Clock
+	NodeID   string           // the node in the dots of local writes
+	Resolver ConflictResolver // nil means LastWriterWins
+
+	// Keep concurrent writes as siblings for the application to resolve instead of applying Resolver
+	KeepSiblings bool
+
+	// How long tombstones are kept before being collected even if not every member has acknowledged them
+	TombstoneTTL time.Duration
+
+	lastTimestamp Timestamp
+	lastCounter   uint64 // highest dot counter this node has issued or seen for itself, across all keys
+}
+
+// Write is one change in a batch; a nil Value deletes the key
+type Write struct {
+	Key   string
+	Value []byte
 }
 
-// DataItem represents a piece of data in the system with versioning
+// DataItem represents one write to a key, identified by its dot and ordered against other writes
+// by the causal context it was made in
 type DataItem struct {
-	Key      string
-	Value    []byte
-	Modified time.Time
-	Version  uint64
+	Key       string
+	Value     []byte
+	Modified  time.Time
+	Timestamp Timestamp
+	Deleted   bool
+	Dot       Dot
+	Context   VersionVector
 }
 
 // SyncRequest represents a synchronization request between nodes
@@ -52,136 +95,254 @@ type SyncResponse struct {
 	Timestamp   time.Time
 }
 
-// Creates a new Merkle tree with specified maximum depth for efficient synchronization
-func NewMerkleTree(maxDepth int) *MerkleTree {
+// Creates a new Merkle tree for the given node with the given number of levels above the buckets,
+// giving 16^maxDepth buckets. The node ID names local writes, so it must be unique in the cluster.
+func NewMerkleTree(nodeID string, maxDepth int) *MerkleTree {
 	return &MerkleTree{
-		Leaves:   make(map[string]*MerkleNode),
-		MaxDepth: maxDepth,
+		Leaves:       make(map[string]*MerkleNode),
+		NodeID:       nodeID,
+		MaxDepth:     maxDepth,
+		TombstoneTTL: DefaultTombstoneTTL,
 	}
 }
 
-// Adds data to the Merkle tree and rebuilds the tree structure with hash computation
-func (mt *MerkleTree) AddData(key string, value []byte, version uint64) error {
+// Adds data to the Merkle tree, superseding any siblings, and rehashes the path to its bucket
+func (mt *MerkleTree) AddData(key string, value []byte) error {
 	mt.mu.Lock()
 	defer mt.mu.Unlock()
 
-	leaf := &MerkleNode{
-		Hash:     hashData(value),
-		Data:     value,
-		IsLeaf:   true,
-		Key:      key,
-		Modified: time.Now(),
-	}
-
-	mt.Leaves[key] = leaf
-	mt.rebuildTree()
+	mt.put(key, []DataItem{mt.localWrite(key, value)})
+	mt.rehash(mt.Root)
 	return nil
 }
 
-// Updates existing data in the Merkle tree and rebuilds the tree with new hash
-func (mt *MerkleTree) UpdateData(key string, value []byte, version uint64) error {
+// Updates existing data in the Merkle tree, superseding any siblings, and rehashes the path to its bucket
+func (mt *MerkleTree) UpdateData(key string, value []byte) error {
 	mt.mu.Lock()
 	defer mt.mu.Unlock()
 
-	if leaf, exists := mt.Leaves[key]; exists {
-		leaf.Hash = hashData(value)
-		leaf.Data = value
-		leaf.Modified = time.Now()
-		mt.rebuildTree()
+	if leaf, exists := mt.Leaves[key]; exists && !leaf.Deleted {
+		mt.put(key, []DataItem{mt.localWrite(key, value)})
+		mt.rehash(mt.Root)
 		return nil
 	}
 
 	return fmt.Errorf("key %s not found", key)
 }
 
-// Removes data from the Merkle tree and rebuilds the tree structure
+// Replaces data in the Merkle tree with a tombstone so the delete spreads through sync, and
+// rehashes the path to its bucket
 func (mt *MerkleTree) DeleteData(key string) error {
 	mt.mu.Lock()
 	defer mt.mu.Unlock()
 
-	if _, exists := mt.Leaves[key]; exists {
-		delete(mt.Leaves, key)
-		mt.rebuildTree()
+	if leaf, exists := mt.Leaves[key]; exists && !leaf.Deleted {
+		mt.put(key, []DataItem{mt.localDelete(key)})
+		mt.rehash(mt.Root)
 		return nil
 	}
 
 	return fmt.Errorf("key %s not found", key)
 }
 
-// Retrieves data from the Merkle tree by key with read-safe access
+// Applies a batch of writes and deletes, rehashing each affected node once
+func (mt *MerkleTree) WriteBatch(writes []Write) error {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	for _, w := range writes {
+		if w.Value == nil {
+			if leaf, exists := mt.Leaves[w.Key]; exists && !leaf.Deleted {
+				mt.put(w.Key, []DataItem{mt.localDelete(w.Key)})
+			}
+		} else {
+			mt.put(w.Key, []DataItem{mt.localWrite(w.Key, w.Value)})
+		}
+	}
+	mt.rehash(mt.Root)
+	return nil
+}
+
+// Retrieves data from the Merkle tree by key with read-safe access; when concurrent writes are kept
+// as siblings, returns the last-writer-wins pick among them
 func (mt *MerkleTree) GetData(key string) ([]byte, error) {
 	mt.mu.RLock()
 	defer mt.mu.RUnlock()
 
-	if leaf, exists := mt.Leaves[key]; exists {
+	if leaf, exists := mt.Leaves[key]; exists && !leaf.Deleted {
 		return leaf.Data, nil
 	}
 
 	return nil, fmt.Errorf("key %s not found", key)
 }
 
+// Returns every concurrent write held for a key, including deletes, so the application can resolve them
+// by writing the key again
+func (mt *MerkleTree) GetSiblings(key string) []DataItem {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+
+	if leaf, exists := mt.Leaves[key]; exists {
+		return append([]DataItem(nil), leaf.Siblings...)
+	}
+	return nil
+}
+
 // Returns the root hash of the Merkle tree for quick comparison and synchronization
 func (mt *MerkleTree) GetTreeHash() string {
 	mt.mu.RLock()
 	defer mt.mu.RUnlock()
+	return mt.rootHash()
+}
 
+// Returns the root hash; the caller must hold the lock
+func (mt *MerkleTree) rootHash() string {
 	if mt.Root == nil {
 		return ""
 	}
 	return mt.Root.Hash
 }
 
-// Rebuilds the Merkle tree from leaves with consistent ordering for deterministic hashing
-func (mt *MerkleTree) rebuildTree() {
-	if len(mt.Leaves) == 0 {
-		mt.Root = nil
-		return
+// Stamps a local write with a fresh hybrid timestamp and a new dot for this node, made in the
+// context of every sibling held so it supersedes them; the caller must hold the lock
+func (mt *MerkleTree) localWrite(key string, value []byte) DataItem {
+	context := VersionVector{}
+	if leaf, exists := mt.Leaves[key]; exists {
+		context = causalContext(leaf.Siblings)
 	}
+	return DataItem{
+		Key:       key,
+		Value:     value,
+		Modified:  mt.getClock().Now(),
+		Timestamp: mt.tick(),
+		Dot:       mt.nextDot(context),
+		Context:   context,
+	}
+}
 
-	leaves := make([]*MerkleNode, 0, len(mt.Leaves))
-	for _, leaf := range mt.Leaves {
-		leaves = append(leaves, leaf)
+// Stores a key's siblings as a leaf in the map and its bucket, marking the path to it dirty; the
+// caller must hold the lock
+func (mt *MerkleTree) put(key string, siblings []DataItem) {
+	read := visible(siblings)
+	leaf := &MerkleNode{
+		Hash:     siblingsHash(key, siblings),
+		Data:     read.Value,
+		IsLeaf:   true,
+		Key:      key,
+		Deleted:  read.Deleted,
+		Siblings: siblings,
+	}
+	for _, sibling := range siblings {
+		if sibling.Modified.After(leaf.Modified) {
+			leaf.Modified = sibling.Modified
+		}
 	}
-	sort.Slice(leaves, func(i, j int) bool {
-		return leaves[i].Key < leaves[j].Key
-	})
+	mt.Leaves[key] = leaf
 
-	mt.Root = mt.buildTreeLevel(leaves, 0)
+	bucket := mt.bucket(key, true)
+	i := sort.Search(len(bucket.Children), func(i int) bool { return bucket.Children[i].Key >= key })
+	if i < len(bucket.Children) && bucket.Children[i].Key == key {
+		bucket.Children[i] = leaf
+		return
+	}
+	bucket.Children = append(bucket.Children, nil)
+	copy(bucket.Children[i+1:], bucket.Children[i:])
+	bucket.Children[i] = leaf
 }
 
-// Recursively builds tree levels with configurable depth limit for efficient storage
-func (mt *MerkleTree) buildTreeLevel(nodes []*MerkleNode, depth int) *MerkleNode {
-	if len(nodes) == 1 {
-		return nodes[0]
+// Removes a leaf from the map and its bucket, marking the path to it dirty; the caller must hold the lock
+func (mt *MerkleTree) remove(key string) {
+	if _, exists := mt.Leaves[key]; !exists {
+		return
+	}
+	delete(mt.Leaves, key)
+
+	bucket := mt.bucket(key, true)
+	i := sort.Search(len(bucket.Children), func(i int) bool { return bucket.Children[i].Key >= key })
+	if i < len(bucket.Children) && bucket.Children[i].Key == key {
+		bucket.Children = append(bucket.Children[:i], bucket.Children[i+1:]...)
 	}
+}
 
-	if depth >= mt.MaxDepth {
-		combinedHash := ""
-		for _, node := range nodes {
-			combinedHash += node.Hash
+// Returns the bucket holding a key, optionally creating the nodes on the way to it and marking
+// them dirty; the caller must hold the lock
+func (mt *MerkleTree) bucket(key string, mark bool) *MerkleNode {
+	if mt.Root == nil {
+		mt.Root = &MerkleNode{}
+	}
+	node := mt.Root
+	for _, i := range mt.bucketPath(key) {
+		if mark {
+			node.dirty = true
 		}
-		return &MerkleNode{
-			Hash:   hashData([]byte(combinedHash)),
-			IsLeaf: false,
+		if node.Children == nil {
+			node.Children = make([]*MerkleNode, fanout)
 		}
+		if node.Children[i] == nil {
+			node.Children[i] = &MerkleNode{}
+		}
+		node = node.Children[i]
+	}
+	if mark {
+		node.dirty = true
 	}
+	return node
+}
 
-	var pairs []*MerkleNode
-	for i := 0; i < len(nodes); i += 2 {
-		if i+1 < len(nodes) {
-			parentHash := hashData([]byte(nodes[i].Hash + nodes[i+1].Hash))
-			pairs = append(pairs, &MerkleNode{
-				Hash:   parentHash,
-				Left:   nodes[i],
-				Right:  nodes[i+1],
-				IsLeaf: false,
-			})
+// Returns the child indexes leading to a key's bucket: one nibble of the key's hash per level, so
+// a key lands in the same bucket on every node no matter which other keys exist
+func (mt *MerkleTree) bucketPath(key string) []int {
+	sum := sha256.Sum256([]byte(key))
+	path := make([]int, mt.MaxDepth)
+	for level := range path {
+		b := 0
+		if level/2 < len(sum) {
+			b = int(sum[level/2])
+		}
+		if level%2 == 0 {
+			path[level] = b >> 4
 		} else {
-			pairs = append(pairs, nodes[i])
+			path[level] = b & 0xf
+		}
+	}
+	return path
+}
+
+// Recomputes the hashes of dirty nodes bottom-up and prunes empty subtrees; the caller must hold the lock
+func (mt *MerkleTree) rehash(node *MerkleNode) {
+	if node == nil || !node.dirty {
+		return
+	}
+	node.dirty = false
+
+	var combined []byte
+	empty := true
+	for i, child := range node.Children {
+		if child == nil {
+			combined = append(combined, '-')
+			continue
 		}
+		if !child.IsLeaf {
+			mt.rehash(child)
+			if child.Hash == "" {
+				node.Children[i] = nil
+				combined = append(combined, '-')
+				continue
+			}
+		}
+		empty = false
+		combined = append(combined, child.Hash...)
 	}
 
-	return mt.buildTreeLevel(pairs, depth+1)
+	if empty {
+		node.Hash = ""
+		node.Children = nil
+		if node == mt.Root {
+			mt.Root = nil
+		}
+		return
+	}
+	node.Hash = hashData(combined)
 }
 
 // Returns the difference between two Merkle trees for efficient synchronization with minimal data transfer
@@ -189,7 +350,7 @@ func (mt *MerkleTree) GetDiff(otherHash string, otherLeaves map[string]*MerkleNo
 	mt.mu.RLock()
 	defer mt.mu.RUnlock()
 
-	if mt.GetTreeHash() == otherHash {
+	if mt.rootHash() == otherHash {
 		return nil
 	}
 
@@ -198,51 +359,237 @@ func (mt *MerkleTree) GetDiff(otherHash string, otherLeaves map[string]*MerkleNo
 	// Check for changes and additions
 	for key, leaf := range mt.Leaves {
 		if otherLeaf, exists := otherLeaves[key]; !exists || leaf.Hash != otherLeaf.Hash {
-			diff = append(diff, DataItem{
-				Key:      key,
-				Value:    leaf.Data,
-				Modified: leaf.Modified,
-				Version:  0,
-			})
+			diff = append(diff, leaf.Siblings...)
 		}
 	}
 
 	// Check for deletions
 	for key, otherLeaf := range otherLeaves {
 		if _, exists := mt.Leaves[key]; !exists {
-			diff = append(diff, DataItem{
-				Key:      key,
-				Value:    otherLeaf.Data,
-				Modified: otherLeaf.Modified,
-				Version:  0,
-			})
+			diff = append(diff, otherLeaf.Siblings...)
 		}
 	}
 
 	return diff
 }
 
-// Applies a diff to the Merkle tree and rebuilds the structure for consistency
+// Applies a diff to the Merkle tree, replacing writes the items have seen and resolving concurrent
+// ones through the tree's conflict resolver; only items marked Deleted are deletes, so an empty value
+// stays a live value
 func (mt *MerkleTree) ApplyDiff(diff []DataItem) error {
 	mt.mu.Lock()
 	defer mt.mu.Unlock()
 
 	for _, item := range diff {
-		if item.Value == nil {
-			delete(mt.Leaves, item.Key)
-		} else {
-			mt.Leaves[item.Key] = &MerkleNode{
-				Hash:     hashData(item.Value),
-				Data:     item.Value,
-				IsLeaf:   true,
-				Key:      item.Key,
-				Modified: item.Modified,
+		mt.merge(item)
+	}
+
+	mt.rehash(mt.Root)
+	return nil
+}
+
+// Applies the items, including tombstones, that are missing locally or have not been seen by the
+// local copy, keeping or resolving concurrent writes. Returns how many changed the tree.
+func (mt *MerkleTree) MergeDiff(diff []DataItem) int {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	applied := 0
+	for _, item := range diff {
+		if mt.merge(item) {
+			applied++
+		}
+	}
+
+	mt.rehash(mt.Root)
+	return applied
+}
+
+// Stores an item if the key is missing or reconciles it with the local siblings, and reports
+// whether anything changed; the caller must hold the lock
+func (mt *MerkleTree) merge(item DataItem) bool {
+	mt.observe(item.Timestamp)
+	mt.observeDots(item)
+
+	leaf, exists := mt.Leaves[item.Key]
+	if !exists {
+		if item.Deleted && mt.expired(item.Modified) {
+			return false
+		}
+		mt.put(item.Key, []DataItem{item})
+		return true
+	}
+
+	siblings, changed := mt.reconcile(leaf.Siblings, item)
+	if changed {
+		mt.put(item.Key, siblings)
+	}
+	return changed
+}
+
+// Returns the hash of the node at the path, if the tree has one there
+func (mt *MerkleTree) GetSubtree(path []int) (SubtreeHash, bool) {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+
+	node := mt.nodeAt(path)
+	if node == nil {
+		return SubtreeHash{}, false
+	}
+	return subtreeHash(path, node), true
+}
+
+// Returns the hashes of the children of the node at each path; leaves and missing paths have none
+func (mt *MerkleTree) GetChildHashes(paths [][]int) []SubtreeHash {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+
+	var hashes []SubtreeHash
+	for _, path := range paths {
+		node := mt.nodeAt(path)
+		if node == nil {
+			continue
+		}
+		for i, child := range node.Children {
+			if child == nil {
+				continue
 			}
+			hashes = append(hashes, subtreeHash(childPath(path, i), child))
 		}
 	}
+	return hashes
+}
 
-	mt.rebuildTree()
-	return nil
+// Compares a peer's subtree hashes against the local nodes at the same paths, where parents are
+// the paths whose children were requested. Returns the local items the peer is missing or holds
+// different copies of, the keys to fetch from it, and the paths to descend into next. Keys whose
+// copies differ are both pushed and fetched so each side can run its conflict resolver. Tombstones
+// for keys the peer does not hold are not pushed but count as acknowledged by it, since it has no
+// copy left to resurrect.
+func (mt *MerkleTree) CompareSubtrees(peerID string, remote []SubtreeHash, parents [][]int) ([]DataItem, []string, [][]int) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	var push []DataItem
+	var want []string
+	var expand [][]int
+	seen := make(map[string]bool, len(remote))
+	remoteKeys := make(map[string]bool)
+	for _, other := range remote {
+		if !other.IsLeaf {
+			seen[pathKey(other.Path)] = true
+			if local := mt.nodeAt(other.Path); local == nil || local.Hash != other.Hash {
+				expand = append(expand, other.Path)
+			}
+			continue
+		}
+
+		remoteKeys[other.Key] = true
+		leaf, exists := mt.Leaves[other.Key]
+		switch {
+		case exists && leaf.Hash == other.Hash:
+			if leaf.Deleted {
+				ackTombstone(leaf, peerID)
+			}
+		case !exists && other.Deleted:
+			// Nothing here for the tombstone to delete
+		default:
+			want = append(want, other.Key)
+			if exists {
+				push = append(push, leaf.Siblings...)
+			}
+		}
+	}
+
+	// Local keys the peer does not hold: whole subtrees it lacks, or keys missing from a bucket
+	for _, parent := range parents {
+		node := mt.nodeAt(parent)
+		if node == nil {
+			continue
+		}
+		for i, child := range node.Children {
+			if child == nil || (child.IsLeaf && remoteKeys[child.Key]) || (!child.IsLeaf && seen[pathKey(childPath(parent, i))]) {
+				continue
+			}
+			for _, leaf := range collectLeaves(child) {
+				if leaf.Deleted {
+					ackTombstone(leaf, peerID)
+				} else {
+					push = append(push, leaf.Siblings...)
+				}
+			}
+		}
+	}
+
+	return push, want, expand
+}
+
+// Returns the data items for the given keys that exist in the tree
+func (mt *MerkleTree) GetItems(keys []string) []DataItem {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+
+	var items []DataItem
+	for _, key := range keys {
+		if leaf, exists := mt.Leaves[key]; exists {
+			items = append(items, leaf.Siblings...)
+		}
+	}
+	return items
+}
+
+// Returns the node at the path, or nil; the caller must hold the lock
+func (mt *MerkleTree) nodeAt(path []int) *MerkleNode {
+	node := mt.Root
+	for _, i := range path {
+		if node == nil {
+			return nil
+		}
+		if i < 0 || i >= len(node.Children) {
+			return nil
+		}
+		node = node.Children[i]
+	}
+	return node
+}
+
+// Returns every leaf under a node
+func collectLeaves(node *MerkleNode) []*MerkleNode {
+	if node.IsLeaf {
+		return []*MerkleNode{node}
+	}
+	var leaves []*MerkleNode
+	for _, child := range node.Children {
+		if child == nil {
+			continue
+		}
+		leaves = append(leaves, collectLeaves(child)...)
+	}
+	return leaves
+}
+
+// Returns the hash description of a node at a path
+func subtreeHash(path []int, node *MerkleNode) SubtreeHash {
+	return SubtreeHash{
+		Path:    path,
+		Hash:    node.Hash,
+		IsLeaf:  node.IsLeaf,
+		Key:     node.Key,
+		Deleted: node.Deleted,
+	}
+}
+
+// Returns the path of a node's i-th child
+func childPath(path []int, i int) []int {
+	child := make([]int, len(path)+1)
+	copy(child, path)
+	child[len(path)] = i
+	return child
+}
+
+// Returns a map key for a path
+func pathKey(path []int) string {
+	return fmt.Sprint(path)
 }
 
 // Returns a copy of the leaves map for external access with read-safe operations
@@ -257,6 +604,38 @@ func (mt *MerkleTree) GetLeaves() map[string]*MerkleNode {
 	return leaves
 }
 
+// Returns the tree's clock, defaulting to wall clock time
+func (mt *MerkleTree) getClock() clock.Clock {
+	if mt.Clock == nil {
+		return clock.Real{}
+	}
+	return mt.Clock
+}
+
+// Hashes a leaf's key together with its value, or a tombstone marker, so equal values under different
+// keys hash differently and deleted keys differ from live ones. Version metadata is left out, so nodes
+// holding the same value agree on the hash however they got it.
+func leafHash(key string, value []byte, deleted bool) string {
+	if deleted {
+		return hashData([]byte(key + "\x00t"))
+	}
+	return hashData(append([]byte(key+"\x00v"), value...))
+}
+
+// Hashes a key's siblings: a single write hashes like a plain leaf, concurrent writes hash the
+// sorted hashes of each so the result does not depend on the order they arrived in
+func siblingsHash(key string, siblings []DataItem) string {
+	if len(siblings) == 1 {
+		return leafHash(key, siblings[0].Value, siblings[0].Deleted)
+	}
+	hashes := make([]string, len(siblings))
+	for i, sibling := range siblings {
+		hashes[i] = leafHash(key, sibling.Value, sibling.Deleted)
+	}
+	sort.Strings(hashes)
+	return hashData([]byte(key + "\x00s" + strings.Join(hashes, "")))
+}
+
 // Creates a SHA256 hash of the data for tree construction and integrity verification
 func hashData(data []byte) string {
 	hash := sha256.Sum256(data)
@@ -268,9 +647,17 @@ func (mt *MerkleTree) GetStats() map[string]interface{} {
 	mt.mu.RLock()
 	defer mt.mu.RUnlock()
 
+	tombstones := 0
+	for _, leaf := range mt.Leaves {
+		if leaf.Deleted {
+			tombstones++
+		}
+	}
+
 	return map[string]interface{}{
 		"total_leaves": len(mt.Leaves),
-		"tree_hash":    mt.GetTreeHash(),
+		"tombstones":   tombstones,
+		"tree_hash":    mt.rootHash(),
 		"max_depth":    mt.MaxDepth,
 	}
 }
@@ -0,0 +1,368 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/jscottransom/fringe/internal/clock"
+	serial "github.com/jscottransom/fringe/internal/proto"
+	"github.com/jscottransom/fringe/internal/swim"
+)
+
+const syncTimeout = 5 * time.Second
+
+// Service keeps the local Merkle tree converged with the cluster by anti-entropy: it periodically
+// compares root hashes with a random alive peer and exchanges only the keys that differ
+type Service struct {
+	Node     *swim.Node
+	Tree     *MerkleTree
+	Interval time.Duration
+	Clock    clock.Clock
+	Rand     *rand.Rand
+	randMu   sync.Mutex
+}
+
+// Creates a sync service for the node's tree and registers it to answer sync requests on the node's transport
+func NewService(node *swim.Node, tree *MerkleTree, interval time.Duration) *Service {
+	s := &Service{
+		Node:     node,
+		Tree:     tree,
+		Interval: interval,
+	}
+	node.HandleMessages(s.handle)
+	return s
+}
+
+// Syncs with a random alive peer every interval, and with peers as they join or recover,
+// until the context is cancelled
+func (s *Service) Start(ctx context.Context) {
+	events, unsubscribe := s.Node.Subscribe(64)
+	go func() {
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-events:
+				if event.Type != swim.EventJoin && event.Type != swim.EventRefute {
+					continue
+				}
+				if err := s.SyncWith(ctx, event.Peer.Address); err != nil {
+					log.Printf("Sync with new member %s failed: %v", event.Peer.PeerID, err)
+				}
+			}
+		}
+	}()
+
+	var tick func()
+	tick = func() {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.SyncRandomPeer(ctx); err != nil {
+			log.Printf("Periodic sync failed: %v", err)
+		}
+		s.collectTombstones()
+		s.getClock().AfterFunc(s.Interval, tick)
+	}
+	s.getClock().AfterFunc(s.Interval, tick)
+
+	log.Printf("Started anti-entropy sync for node %s every %v", s.Node.NodeId, s.Interval)
+}
+
+// Syncs with one alive peer chosen at random
+func (s *Service) SyncRandomPeer(ctx context.Context) error {
+	candidates := s.livePeers()
+	if len(candidates) == 0 {
+		return nil
+	}
+	peer := candidates[s.randIntn(len(candidates))]
+	return s.SyncWith(ctx, peer.Address)
+}
+
+// Drops tombstones every known member has acknowledged or that have outlived the tree's grace
+// period. Suspected and Dead members count until they are removed, since they may only be cut off
+// and still hold the deleted value; only members that left are skipped.
+func (s *Service) collectTombstones() {
+	var members []string
+	for _, peer := range s.Node.MemberTable.Snapshot() {
+		if peer.PeerID != s.Node.NodeId && peer.State != swim.Left {
+			members = append(members, peer.PeerID)
+		}
+	}
+	if removed := s.Tree.CollectTombstones(members); removed > 0 {
+		log.Printf("Collected %d tombstones", removed)
+	}
+}
+
+// Returns the alive peers other than this node
+func (s *Service) livePeers() []*swim.Peer {
+	var peers []*swim.Peer
+	for _, peer := range s.Node.MemberTable.GetAlivePeers() {
+		if peer.PeerID != s.Node.NodeId {
+			peers = append(peers, peer)
+		}
+	}
+	return peers
+}
+
+// Compares root hashes with the peer, acknowledging its tombstones when they match, and if they differ, descends level by level into only the
+// subtrees whose hashes differ, then swaps the differing keys so each side can resolve conflicts
+// with its own resolver
+func (s *Service) SyncWith(ctx context.Context, addr string) error {
+	ctx, cancel := clock.WithTimeout(ctx, s.getClock(), syncTimeout)
+	defer cancel()
+
+	resp, err := s.request(ctx, addr, &serial.SyncRequest{
+		RequestorId: s.Node.NodeId,
+		TreeHash:    s.Tree.GetTreeHash(),
+	})
+	if err != nil {
+		return err
+	}
+	peerID := resp.ResponderId
+	if resp.TreeHash == s.Tree.GetTreeHash() {
+		s.Tree.AckTombstones(peerID)
+		return nil
+	}
+
+	// A key with concurrent writes is pushed as one item per sibling, so writes are told apart by dot
+	type write struct {
+		key string
+		dot Dot
+	}
+	pushed := make(map[write]bool)
+	var push []DataItem
+	var want []string
+	var parents [][]int
+	subtrees := fromProtoSubtrees(resp.Subtrees)
+	if resp.TreeHash == "" {
+		// The peer is empty, so everything under our root is missing there
+		parents = [][]int{nil}
+	}
+	rounds := 0
+	for {
+		items, keys, expand := s.Tree.CompareSubtrees(peerID, subtrees, parents)
+		for _, item := range items {
+			if id := (write{item.Key, item.Dot}); !pushed[id] {
+				pushed[id] = true
+				push = append(push, item)
+			}
+		}
+		want = append(want, keys...)
+		if len(expand) == 0 {
+			break
+		}
+
+		rounds++
+		resp, err = s.request(ctx, addr, &serial.SyncRequest{
+			RequestorId: s.Node.NodeId,
+			Expand:      toProtoPaths(expand),
+		})
+		if err != nil {
+			return err
+		}
+		subtrees = fromProtoSubtrees(resp.Subtrees)
+		parents = expand
+	}
+	if len(push) == 0 && len(want) == 0 {
+		return nil
+	}
+
+	resp, err = s.request(ctx, addr, &serial.SyncRequest{
+		RequestorId: s.Node.NodeId,
+		Items:       toProtoItems(push),
+		Want:        want,
+	})
+	if err != nil {
+		return err
+	}
+	applied := s.Tree.MergeDiff(fromProtoItems(resp.Items))
+	log.Printf("Synced with %s after %d rounds: pushed %d writes, pulled %d of %d wanted", resp.ResponderId, rounds, len(push), applied, len(want))
+	return nil
+}
+
+// Sends a sync request and returns the peer's response, failing if the peer's tree has a different
+// layout since its subtree hashes would not be comparable
+func (s *Service) request(ctx context.Context, addr string, req *serial.SyncRequest) (*serial.SyncResponse, error) {
+	req.Depth = uint32(s.Tree.MaxDepth)
+	reply, err := s.Node.Exchange(ctx, addr, &serial.Envelope{Msg: &serial.Envelope_SyncRequest{SyncRequest: req}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync with %s: %w", addr, err)
+	}
+	resp := reply.GetSyncResponse()
+	if resp == nil {
+		return nil, fmt.Errorf("no sync response from %s", addr)
+	}
+	if resp.Depth != req.Depth {
+		return nil, fmt.Errorf("peer %s has tree depth %d, expected %d", addr, resp.Depth, req.Depth)
+	}
+	return resp, nil
+}
+
+// Answers a sync request: merges pushed items, then returns wanted items, the children of the
+// subtrees to expand or, on a root hash mismatch, the root itself. Requests from trees with a
+// different layout only get this tree's depth back.
+func (s *Service) handle(env *serial.Envelope) *serial.Envelope {
+	req := env.GetSyncRequest()
+	if req == nil {
+		return nil
+	}
+
+	resp := &serial.SyncResponse{
+		ResponderId: s.Node.NodeId,
+		Depth:       uint32(s.Tree.MaxDepth),
+	}
+	if req.Depth != resp.Depth {
+		log.Printf("Rejecting sync from %s: tree depth %d, expected %d", req.RequestorId, req.Depth, resp.Depth)
+		return &serial.Envelope{Msg: &serial.Envelope_SyncResponse{SyncResponse: resp}}
+	}
+
+	s.Tree.MergeDiff(fromProtoItems(req.Items))
+	resp.TreeHash = s.Tree.GetTreeHash()
+	if req.TreeHash == resp.TreeHash {
+		s.Tree.AckTombstones(req.RequestorId)
+	}
+
+	switch {
+	case len(req.Want) > 0:
+		resp.Items = toProtoItems(s.Tree.GetItems(req.Want))
+	case len(req.Expand) > 0:
+		resp.Subtrees = toProtoSubtrees(s.Tree.GetChildHashes(fromProtoPaths(req.Expand)))
+	case len(req.Items) == 0 && req.TreeHash != resp.TreeHash:
+		if root, ok := s.Tree.GetSubtree(nil); ok {
+			resp.Subtrees = toProtoSubtrees([]SubtreeHash{root})
+		}
+	}
+	return &serial.Envelope{Msg: &serial.Envelope_SyncResponse{SyncResponse: resp}}
+}
+
+// Returns the service's clock, defaulting to wall clock time
+func (s *Service) getClock() clock.Clock {
+	if s.Clock == nil {
+		return clock.Real{}
+	}
+	return s.Clock
+}
+
+// Returns a pseudo-random number in [0, k) from the service's source, defaulting to the global one
+func (s *Service) randIntn(k int) int {
+	if s.Rand == nil {
+		return rand.Intn(k)
+	}
+	s.randMu.Lock()
+	defer s.randMu.Unlock()
+	return s.Rand.Intn(k)
+}
+
+// Converts data items to their wire form
+func toProtoItems(items []DataItem) []*serial.DataItem {
+	out := make([]*serial.DataItem, len(items))
+	for i, item := range items {
+		out[i] = &serial.DataItem{
+			Key:        item.Key,
+			Value:      item.Value,
+			Modified:   item.Modified.UnixNano(),
+			WallTime:   item.Timestamp.WallTime,
+			Logical:    item.Timestamp.Logical,
+			Deleted:    item.Deleted,
+			DotNode:    item.Dot.Node,
+			DotCounter: item.Dot.Counter,
+			Context:    item.Context,
+		}
+	}
+	return out
+}
+
+// Converts data items from their wire form
+func fromProtoItems(items []*serial.DataItem) []DataItem {
+	out := make([]DataItem, len(items))
+	for i, item := range items {
+		out[i] = DataItem{
+			Key:      item.Key,
+			Value:    item.Value,
+			Modified: time.Unix(0, item.Modified),
+			Timestamp: Timestamp{
+				WallTime: item.WallTime,
+				Logical:  item.Logical,
+			},
+			Deleted: item.Deleted,
+			Dot: Dot{
+				Node:    item.DotNode,
+				Counter: item.DotCounter,
+			},
+			Context: VersionVector(item.Context),
+		}
+	}
+	return out
+}
+
+// Converts subtree hashes to their wire form
+func toProtoSubtrees(hashes []SubtreeHash) []*serial.SubtreeHash {
+	out := make([]*serial.SubtreeHash, len(hashes))
+	for i, hash := range hashes {
+		out[i] = &serial.SubtreeHash{
+			Path:    toProtoPath(hash.Path),
+			Hash:    hash.Hash,
+			Leaf:    hash.IsLeaf,
+			Key:     hash.Key,
+			Deleted: hash.Deleted,
+		}
+	}
+	return out
+}
+
+// Converts subtree hashes from their wire form
+func fromProtoSubtrees(hashes []*serial.SubtreeHash) []SubtreeHash {
+	out := make([]SubtreeHash, len(hashes))
+	for i, hash := range hashes {
+		out[i] = SubtreeHash{
+			Path:    fromProtoPath(hash.Path),
+			Hash:    hash.Hash,
+			IsLeaf:  hash.Leaf,
+			Key:     hash.Key,
+			Deleted: hash.Deleted,
+		}
+	}
+	return out
+}
+
+// Converts tree paths to their wire form
+func toProtoPaths(paths [][]int) []*serial.TreePath {
+	out := make([]*serial.TreePath, len(paths))
+	for i, path := range paths {
+		out[i] = &serial.TreePath{Steps: toProtoPath(path)}
+	}
+	return out
+}
+
+// Converts tree paths from their wire form
+func fromProtoPaths(paths []*serial.TreePath) [][]int {
+	out := make([][]int, len(paths))
+	for i, path := range paths {
+		out[i] = fromProtoPath(path.Steps)
+	}
+	return out
+}
+
+// Converts a path of child indexes to its wire form
+func toProtoPath(path []int) []uint32 {
+	out := make([]uint32, len(path))
+	for i, step := range path {
+		out[i] = uint32(step)
+	}
+	return out
+}
+
+// Converts a path of child indexes from its wire form
+func fromProtoPath(path []uint32) []int {
+	out := make([]int, len(path))
+	for i, step := range path {
+		out[i] = int(step)
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package sync
+
+import "time"
+
+// DefaultTombstoneTTL is how long a tombstone is kept when not every member has acknowledged it
+const DefaultTombstoneTTL = 24 * time.Hour
+
+// Returns a tombstone for a local delete, stamped like a write; the caller must hold the lock
+func (mt *MerkleTree) localDelete(key string) DataItem {
+	item := mt.localWrite(key, nil)
+	item.Deleted = true
+	return item
+}
+
+// Records that a peer holds every tombstone currently in the tree, as shown by matching root hashes
+func (mt *MerkleTree) AckTombstones(peerID string) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	for _, leaf := range mt.Leaves {
+		if leaf.Deleted {
+			ackTombstone(leaf, peerID)
+		}
+	}
+}
+
+// Records that a peer holds a tombstone or has no copy of its key; the caller must hold the lock
+func ackTombstone(leaf *MerkleNode, peerID string) {
+	if leaf.acked == nil {
+		leaf.acked = make(map[string]bool)
+	}
+	leaf.acked[peerID] = true
+}
+
+// Removes tombstones that every given member has acknowledged or that have outlived the grace
+// period, returning how many were removed. With no members given, only the grace period applies,
+// since a node that knows of no peers may just be cut off from them.
+func (mt *MerkleTree) CollectTombstones(members []string) int {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	removed := 0
+	for key, leaf := range mt.Leaves {
+		if leaf.Deleted && (mt.expired(leaf.Modified) || ackedByAll(leaf, members)) {
+			mt.remove(key)
+			removed++
+		}
+	}
+
+	mt.rehash(mt.Root)
+	return removed
+}
+
+// Reports whether a tombstone deleted at the given time is past the grace period; the caller must hold the lock
+func (mt *MerkleTree) expired(deleted time.Time) bool {
+	return mt.TombstoneTTL > 0 && mt.getClock().Since(deleted) >= mt.TombstoneTTL
+}
+
+// Reports whether every member has acknowledged a tombstone; an empty member set acknowledges nothing
+func ackedByAll(leaf *MerkleNode, members []string) bool {
+	if len(members) == 0 {
+		return false
+	}
+	for _, member := range members {
+		if !leaf.acked[member] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,249 @@
+package sync
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// Timestamp is a hybrid logical clock reading: wall time in nanoseconds plus a logical counter
+// that orders writes made within the same wall time or behind a peer's clock
+type Timestamp struct {
+	WallTime int64
+	Logical  uint32
+}
+
+// Compares two timestamps, returning -1, 0 or 1
+func (t Timestamp) Compare(other Timestamp) int {
+	switch {
+	case t.WallTime < other.WallTime:
+		return -1
+	case t.WallTime > other.WallTime:
+		return 1
+	case t.Logical < other.Logical:
+		return -1
+	case t.Logical > other.Logical:
+		return 1
+	}
+	return 0
+}
+
+// Dot identifies a single write to a key: the node that made it and that node's write counter
+type Dot struct {
+	Node    string
+	Counter uint64
+}
+
+// Orders dots by node ID and then counter
+func (d Dot) before(other Dot) bool {
+	if d.Node != other.Node {
+		return d.Node < other.Node
+	}
+	return d.Counter < other.Counter
+}
+
+// VersionVector maps node IDs to the highest counter of each node's writes to a key that have been seen
+type VersionVector map[string]uint64
+
+// Reports whether the write identified by the dot has been seen
+func (vv VersionVector) Covers(dot Dot) bool {
+	return dot.Counter > 0 && vv[dot.Node] >= dot.Counter
+}
+
+// Reports whether every write seen by the other vector has been seen by this one
+func (vv VersionVector) Descends(other VersionVector) bool {
+	for node, counter := range other {
+		if vv[node] < counter {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns a new vector holding the highest counter for each node from both vectors
+func (vv VersionVector) Merge(other VersionVector) VersionVector {
+	merged := make(VersionVector, len(vv)+len(other))
+	for node, counter := range vv {
+		merged[node] = counter
+	}
+	for node, counter := range other {
+		if counter > merged[node] {
+			merged[node] = counter
+		}
+	}
+	return merged
+}
+
+// Returns a new vector that has also seen the write identified by the dot
+func (vv VersionVector) With(dot Dot) VersionVector {
+	merged := vv.Merge(nil)
+	if dot.Counter > merged[dot.Node] {
+		merged[dot.Node] = dot.Counter
+	}
+	return merged
+}
+
+// ConflictResolver picks the copy of a key to keep when two writes are concurrent, which are passed
+// in dot order. It may also return a merged item, which becomes a new write by the resolving node;
+// merging two equal merged values must then give the same value back, or nodes that resolve the
+// conflict at the same time keep merging each other's results. It must be deterministic so every
+// node settles on the same value.
+type ConflictResolver func(local, remote DataItem) DataItem
+
+// Resolves conflicts by keeping the copy with the later hybrid timestamp, breaking ties by the
+// higher writer node ID and then by value
+func LastWriterWins(local, remote DataItem) DataItem {
+	if c := remote.Timestamp.Compare(local.Timestamp); c != 0 {
+		if c > 0 {
+			return remote
+		}
+		return local
+	}
+	if c := strings.Compare(remote.Dot.Node, local.Dot.Node); c != 0 {
+		if c > 0 {
+			return remote
+		}
+		return local
+	}
+	if bytes.Compare(remote.Value, local.Value) > 0 {
+		return remote
+	}
+	return local
+}
+
+// Reconciles an incoming copy of a key with the local siblings: a copy already seen by a sibling is
+// dropped, siblings the copy has seen are replaced by it, and the rest are concurrent and kept
+// alongside it, or folded into one by the resolver unless the tree keeps siblings. Reports whether
+// anything changed; the caller must hold the lock.
+func (mt *MerkleTree) reconcile(siblings []DataItem, item DataItem) ([]DataItem, bool) {
+	kept := make([]DataItem, 0, len(siblings)+1)
+	for i, sibling := range siblings {
+		if item.Dot.Counter > 0 && sibling.Dot == item.Dot {
+			// Same write; only its causal context can have grown, and may now cover other siblings
+			if sibling.Context.Descends(item.Context) {
+				return siblings, false
+			}
+			sibling.Context = sibling.Context.Merge(item.Context)
+			kept = kept[:0]
+			for j, other := range siblings {
+				if j == i {
+					kept = append(kept, sibling)
+				} else if !sibling.Context.Covers(other.Dot) {
+					kept = append(kept, other)
+				}
+			}
+			return kept, true
+		}
+		if sibling.Context.Covers(item.Dot) {
+			return siblings, false
+		}
+		if !item.Context.Covers(sibling.Dot) {
+			kept = append(kept, sibling)
+		}
+	}
+
+	kept = append(kept, item)
+	if len(kept) > 1 && !mt.KeepSiblings {
+		kept = []DataItem{mt.resolve(kept)}
+	}
+	return kept, true
+}
+
+// Folds concurrent siblings into one with the tree's resolver; the result's context covers every
+// sibling, so it supersedes all of them on any node that still holds one. A merged result gets a
+// fresh local dot, since a peer holding the sibling whose dot it kept would take it for that same
+// write and never pick up the merged value. The caller must hold the lock.
+func (mt *MerkleTree) resolve(siblings []DataItem) DataItem {
+	ordered := append([]DataItem(nil), siblings...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Dot.before(ordered[j].Dot) })
+
+	resolver := mt.getResolver()
+	winner := ordered[0]
+	for _, sibling := range ordered[1:] {
+		winner = resolver(winner, sibling)
+	}
+	winner.Key = ordered[0].Key
+
+	context := causalContext(ordered)
+	if !isSibling(ordered, winner) {
+		winner.Dot = mt.nextDot(context)
+		winner.Timestamp = mt.tick()
+		winner.Modified = mt.getClock().Now()
+	}
+	winner.Context = context
+	return winner
+}
+
+// Reports whether an item is one of the siblings unchanged rather than a merge of them
+func isSibling(siblings []DataItem, item DataItem) bool {
+	for _, sibling := range siblings {
+		if sibling.Dot == item.Dot && sibling.Deleted == item.Deleted && bytes.Equal(sibling.Value, item.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the causal context of a key's siblings: every write any of them has seen, plus their own
+func causalContext(siblings []DataItem) VersionVector {
+	context := VersionVector{}
+	for _, sibling := range siblings {
+		context = context.Merge(sibling.Context).With(sibling.Dot)
+	}
+	return context
+}
+
+// Returns the sibling reads see: the last-writer-wins pick among live siblings, or a tombstone if all are deleted
+func visible(siblings []DataItem) DataItem {
+	pick := siblings[0]
+	for _, sibling := range siblings[1:] {
+		if pick.Deleted || (!sibling.Deleted && LastWriterWins(pick, sibling).Dot == sibling.Dot) {
+			pick = sibling
+		}
+	}
+	return pick
+}
+
+// Returns a timestamp for a local write, ahead of every timestamp issued or observed so far;
+// the caller must hold the lock
+func (mt *MerkleTree) tick() Timestamp {
+	wall := mt.getClock().Now().UnixNano()
+	if wall > mt.lastTimestamp.WallTime {
+		mt.lastTimestamp = Timestamp{WallTime: wall}
+	} else {
+		mt.lastTimestamp.Logical++
+	}
+	return mt.lastTimestamp
+}
+
+// Advances the hybrid clock past a timestamp received from a peer; the caller must hold the lock
+func (mt *MerkleTree) observe(ts Timestamp) {
+	if ts.Compare(mt.lastTimestamp) > 0 {
+		mt.lastTimestamp = ts
+	}
+}
+
+// Returns a new dot for a local write made in the given context. The counter comes from the whole
+// tree rather than the key's siblings, so a key rewritten after its tombstone was collected does not
+// reuse a dot a peer may still hold; the caller must hold the lock.
+func (mt *MerkleTree) nextDot(context VersionVector) Dot {
+	mt.lastCounter = max(mt.lastCounter, context[mt.NodeID]) + 1
+	return Dot{Node: mt.NodeID, Counter: mt.lastCounter}
+}
+
+// Advances the write counter past this node's writes that a peer has seen, such as ones made before
+// a restart; the caller must hold the lock
+func (mt *MerkleTree) observeDots(item DataItem) {
+	if item.Dot.Node == mt.NodeID {
+		mt.lastCounter = max(mt.lastCounter, item.Dot.Counter)
+	}
+	mt.lastCounter = max(mt.lastCounter, item.Context[mt.NodeID])
+}
+
+// Returns the tree's conflict resolver, defaulting to last-writer-wins
+func (mt *MerkleTree) getResolver() ConflictResolver {
+	if mt.Resolver == nil {
+		return LastWriterWins
+	}
+	return mt.Resolver
+}
@@ -1,25 +1,31 @@
 package tests
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	gosync "sync"
 	"testing"
 	"time"
 
+	"github.com/jscottransom/fringe/internal/clock"
 	serial "github.com/jscottransom/fringe/internal/proto"
 	"github.com/jscottransom/fringe/internal/swim"
 	"github.com/jscottransom/fringe/internal/sync"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestMerkleTreeBasic(t *testing.T) {
 	// Create a new Merkle tree
-	tree := sync.NewMerkleTree(4)
+	tree := sync.NewMerkleTree("node-1", 4)
 
 	// Add some test data
-	err := tree.AddData("key1", []byte("value1"), 1)
+	err := tree.AddData("key1", []byte("value1"))
 	if err != nil {
 		t.Fatalf("Failed to add data: %v", err)
 	}
 
-	err = tree.AddData("key2", []byte("value2"), 1)
+	err = tree.AddData("key2", []byte("value2"))
 	if err != nil {
 		t.Fatalf("Failed to add data: %v", err)
 	}
@@ -48,15 +54,15 @@ func TestMerkleTreeBasic(t *testing.T) {
 
 func TestMerkleTreeDiff(t *testing.T) {
 	// Create two Merkle trees
-	tree1 := sync.NewMerkleTree(4)
-	tree2 := sync.NewMerkleTree(4)
+	tree1 := sync.NewMerkleTree("node-1", 4)
+	tree2 := sync.NewMerkleTree("node-2", 4)
 
 	// Add same data to both trees
-	tree1.AddData("key1", []byte("value1"), 1)
-	tree1.AddData("key2", []byte("value2"), 1)
+	tree1.AddData("key1", []byte("value1"))
+	tree1.AddData("key2", []byte("value2"))
 
-	tree2.AddData("key1", []byte("value1"), 1)
-	tree2.AddData("key2", []byte("value2"), 1)
+	tree2.AddData("key1", []byte("value1"))
+	tree2.AddData("key2", []byte("value2"))
 
 	// Should be no diff
 	diff := tree1.GetDiff(tree2.GetTreeHash(), tree2.GetLeaves())
@@ -65,7 +71,7 @@ func TestMerkleTreeDiff(t *testing.T) {
 	}
 
 	// Add different data to tree2
-	tree2.AddData("key3", []byte("value3"), 1)
+	tree2.AddData("key3", []byte("value3"))
 
 	// Should have diff
 	diff = tree1.GetDiff(tree2.GetTreeHash(), tree2.GetLeaves())
@@ -206,3 +212,781 @@ func TestPiggyBackQueue(t *testing.T) {
 		t.Fatalf("Expected 0 entries from second GetEntries, got %d", len(entries))
 	}
 }
+
+func TestSWIMSuspicionTimerMarksDead(t *testing.T) {
+	memberTable := &swim.NodeTable{
+		Members: make(map[string]*swim.Peer),
+	}
+
+	for _, id := range []string{"quiet-peer", "refuting-peer"} {
+		memberTable.AddPeer(id, &swim.Peer{
+			PeerID:           id,
+			Address:          "127.0.0.1:8080",
+			State:            swim.Suspected,
+			Incarnation:      1,
+			SinceStateUpdate: time.Now(),
+		})
+	}
+
+	dead := make(chan swim.Peer, 2)
+	for _, id := range []string{"quiet-peer", "refuting-peer"} {
+		memberTable.StartSuspicion(id, 1, 20*time.Millisecond, func(peer swim.Peer) {
+			dead <- peer
+		})
+	}
+
+	// Refute one suspicion with a higher incarnation before the timer fires
+	memberTable.UpdatePeer(&serial.MembershipUpdate{
+		NodeId:      "refuting-peer",
+		Address:     "127.0.0.1:8080",
+		Incarnation: 2,
+		State:       serial.State_ALIVE,
+	}, false)
+
+	select {
+	case peer := <-dead:
+		if peer.PeerID != "quiet-peer" || peer.State != swim.Dead {
+			t.Fatalf("Expected quiet-peer to be declared dead, got %s in state %v", peer.PeerID, peer.State)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected suspicion timer to expire")
+	}
+
+	select {
+	case peer := <-dead:
+		t.Fatalf("Expected refuted peer to stay alive, but %s was declared dead", peer.PeerID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSWIMUpdatePeerLearnsUnknownPeers(t *testing.T) {
+	memberTable := &swim.NodeTable{
+		Members: make(map[string]*swim.Peer),
+	}
+
+	// Second-hand ALIVE gossip about an unknown peer inserts it
+	peer, changed := memberTable.UpdatePeer(&serial.MembershipUpdate{
+		NodeId:      "gossiped-peer",
+		Address:     "127.0.0.1:8081",
+		Incarnation: 4,
+		State:       serial.State_ALIVE,
+	}, false)
+	if !changed || peer.Address != "127.0.0.1:8081" || peer.Incarnation != 4 {
+		t.Fatalf("Expected gossiped peer to be inserted, got %+v (changed=%v)", peer, changed)
+	}
+	if size := memberTable.GetClusterSize(); size != 1 {
+		t.Fatalf("Expected cluster size 1, got %d", size)
+	}
+
+	// DEAD gossip about an unknown peer is ignored
+	if _, changed := memberTable.UpdatePeer(&serial.MembershipUpdate{
+		NodeId:      "dead-peer",
+		Address:     "127.0.0.1:8082",
+		Incarnation: 1,
+		State:       serial.State_DEAD,
+	}, false); changed {
+		t.Fatal("Expected DEAD update about unknown peer to be ignored")
+	}
+	if len(memberTable.Members) != 1 {
+		t.Fatalf("Expected 1 member, got %d", len(memberTable.Members))
+	}
+}
+
+func TestSWIMDynamicSuspicionShrinksWithConfirmations(t *testing.T) {
+	memberTable := &swim.NodeTable{
+		Members: make(map[string]*swim.Peer),
+	}
+	memberTable.AddPeer("suspect", &swim.Peer{
+		PeerID:           "suspect",
+		Address:          "127.0.0.1:8080",
+		State:            swim.Suspected,
+		Incarnation:      1,
+		SinceStateUpdate: time.Now(),
+	})
+
+	dead := make(chan swim.Peer, 1)
+	start := time.Now()
+	memberTable.StartDynamicSuspicion("suspect", 1, "accuser", 20*time.Millisecond, 10*time.Second, 2, func(peer swim.Peer) {
+		dead <- peer
+	})
+
+	if memberTable.ConfirmSuspicion("suspect", 1, "accuser") {
+		t.Fatal("Expected the original accuser not to count as a confirmation")
+	}
+	if !memberTable.ConfirmSuspicion("suspect", 1, "witness-1") {
+		t.Fatal("Expected first independent confirmation to be accepted")
+	}
+	if memberTable.ConfirmSuspicion("suspect", 1, "witness-1") {
+		t.Fatal("Expected duplicate confirmation to be rejected")
+	}
+	if !memberTable.ConfirmSuspicion("suspect", 1, "witness-2") {
+		t.Fatal("Expected second independent confirmation to be accepted")
+	}
+
+	// With k confirmations the timeout falls to the minimum
+	select {
+	case <-dead:
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Fatalf("Expected confirmations to shorten suspicion, took %v", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected confirmed suspicion to expire near the minimum timeout")
+	}
+}
+
+func TestSWIMSuspicionExpiresOnFakeClock(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	memberTable := &swim.NodeTable{
+		Members: make(map[string]*swim.Peer),
+		Clock:   clk,
+	}
+	memberTable.AddPeer("quiet-peer", &swim.Peer{
+		PeerID:           "quiet-peer",
+		Address:          "127.0.0.1:8080",
+		State:            swim.Suspected,
+		Incarnation:      1,
+		SinceStateUpdate: clk.Now(),
+	})
+
+	var dead []swim.Peer
+	memberTable.StartSuspicion("quiet-peer", 1, 20*time.Second, func(peer swim.Peer) {
+		dead = append(dead, peer)
+	})
+
+	clk.Advance(19 * time.Second)
+	if len(dead) != 0 {
+		t.Fatalf("Expected suspicion to still be pending, got %d dead peers", len(dead))
+	}
+
+	clk.Advance(time.Second)
+	if len(dead) != 1 || dead[0].State != swim.Dead {
+		t.Fatalf("Expected quiet-peer to be declared dead at the timeout, got %v", dead)
+	}
+	if !dead[0].SinceStateUpdate.Equal(clk.Now()) {
+		t.Fatalf("Expected state change stamped at %v, got %v", clk.Now(), dead[0].SinceStateUpdate)
+	}
+
+	// Dead peers are reaped once they outlive the TTL
+	memberTable.RemoveExpired(swim.PeerTTL)
+	if _, ok := memberTable.GetPeer("quiet-peer"); !ok {
+		t.Fatal("Expected dead peer to be kept until the TTL passes")
+	}
+	clk.Advance(swim.PeerTTL + time.Second)
+	memberTable.RemoveExpired(swim.PeerTTL)
+	if _, ok := memberTable.GetPeer("quiet-peer"); ok {
+		t.Fatal("Expected dead peer to be removed after the TTL")
+	}
+}
+
+func TestPiggyBackQueueEvictsOnFakeClock(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	queue := &swim.PiggyBackQueue{
+		Entries:  make([]*swim.Entry, 0),
+		Capacity: 10,
+		Clock:    clk,
+	}
+	queue.AddEntry(&swim.Entry{
+		Update: &serial.MembershipUpdate{
+			NodeId:      "test-peer",
+			Address:     "127.0.0.1:8080",
+			Incarnation: 1,
+			State:       serial.State_ALIVE,
+		},
+		Expiry:    clk.Now().Add(swim.PeerTTL),
+		SeenPeers: make(map[string]bool),
+	})
+
+	clk.Advance(swim.PeerTTL)
+	queue.EvictEntry()
+	if len(queue.Entries) != 1 {
+		t.Fatalf("Expected entry to survive until its expiry, got %d entries", len(queue.Entries))
+	}
+
+	clk.Advance(time.Millisecond)
+	queue.EvictEntry()
+	if len(queue.Entries) != 0 {
+		t.Fatalf("Expected expired entry to be evicted, got %d entries", len(queue.Entries))
+	}
+}
+
+func TestMerkleTreeStampsModifiedFromClock(t *testing.T) {
+	clk := clock.NewFake(time.Unix(100, 0))
+	tree := sync.NewMerkleTree("node-1", 4)
+	tree.Clock = clk
+
+	tree.AddData("key1", []byte("value1"))
+	first := tree.GetLeaves()["key1"].Modified
+
+	clk.Advance(time.Minute)
+	tree.UpdateData("key1", []byte("value2"))
+	second := tree.GetLeaves()["key1"].Modified
+
+	if !first.Equal(time.Unix(100, 0)) {
+		t.Fatalf("Expected first write stamped at the fake time, got %v", first)
+	}
+	if second.Sub(first) != time.Minute {
+		t.Fatalf("Expected later write to be stamped one minute later, got %v", second.Sub(first))
+	}
+}
+
+func TestSWIMSetMetaRejectsOversizedMetadata(t *testing.T) {
+	memberTable := &swim.NodeTable{
+		Members: make(map[string]*swim.Peer),
+	}
+	memberTable.AddPeer("self", &swim.Peer{
+		PeerID:      "self",
+		Address:     "127.0.0.1:8080",
+		State:       swim.Alive,
+		Incarnation: 1,
+	})
+	node := &swim.Node{
+		NodeId:      "self",
+		MemberTable: memberTable,
+		Queue: &swim.PiggyBackQueue{
+			Entries:  make([]*swim.Entry, 0),
+			Capacity: 10,
+		},
+	}
+
+	err := node.SetMeta(map[string]string{"blob": strings.Repeat("x", swim.MaxMetaSize)})
+	if !errors.Is(err, swim.ErrMetaTooLarge) {
+		t.Fatalf("Expected ErrMetaTooLarge, got %v", err)
+	}
+
+	if err := node.SetMeta(map[string]string{"region": "us-east"}); err != nil {
+		t.Fatalf("Failed to set metadata: %v", err)
+	}
+	self, _ := memberTable.GetPeer("self")
+	if self.Incarnation != 2 || self.Meta["region"] != "us-east" {
+		t.Fatalf("Expected metadata at incarnation 2, got %d with %v", self.Incarnation, self.Meta)
+	}
+
+	// Metadata from a newer incarnation replaces the old tags
+	memberTable.UpdatePeer(&serial.MembershipUpdate{
+		NodeId:      "remote",
+		Address:     "127.0.0.1:8081",
+		Incarnation: 1,
+		State:       serial.State_ALIVE,
+		Meta:        map[string]string{"region": "us-east"},
+	}, false)
+	memberTable.UpdatePeer(&serial.MembershipUpdate{
+		NodeId:      "remote",
+		Address:     "127.0.0.1:8081",
+		Incarnation: 2,
+		State:       serial.State_ALIVE,
+		Meta:        map[string]string{"region": "eu-west"},
+	}, false)
+	if peers := memberTable.GetPeersByTag("region", "us-east"); len(peers) != 1 || peers[0].PeerID != "self" {
+		t.Fatalf("Expected only self tagged us-east, got %v", peers)
+	}
+}
+
+func TestPiggyBackQueueRetransmitLimitScalesWithClusterSize(t *testing.T) {
+	for _, tc := range []struct {
+		nodes int
+		limit int
+	}{
+		{nodes: 5, limit: 4},
+		{nodes: 50, limit: 8},
+		{nodes: 500, limit: 12},
+	} {
+		queue := &swim.PiggyBackQueue{
+			Entries:  make([]*swim.Entry, 0),
+			Capacity: 10,
+			NumNodes: func() int { return tc.nodes },
+		}
+		if limit := queue.RetransmitLimit(); limit != tc.limit {
+			t.Fatalf("Expected retransmit limit %d for %d nodes, got %d", tc.limit, tc.nodes, limit)
+		}
+
+		queue.AddEntry(&swim.Entry{
+			Update:    &serial.MembershipUpdate{NodeId: "test-peer", State: serial.State_ALIVE},
+			Expiry:    time.Now().Add(swim.PeerTTL),
+			SeenPeers: make(map[string]bool),
+		})
+		sent := 0
+		for i := 0; i < 2*tc.limit; i++ {
+			sent += len(queue.GetEntries(fmt.Sprintf("peer-%d", i), 5))
+		}
+		if sent != tc.limit {
+			t.Fatalf("Expected update to be sent %d times in a %d node cluster, got %d", tc.limit, tc.nodes, sent)
+		}
+	}
+}
+
+func TestPiggyBackQueueConcurrentUse(t *testing.T) {
+	queue := &swim.PiggyBackQueue{
+		Entries:  make([]*swim.Entry, 0),
+		Capacity: 10,
+		NumNodes: func() int { return 20 },
+	}
+
+	var wg gosync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				queue.AddEntry(&swim.Entry{
+					Update:    &serial.MembershipUpdate{NodeId: fmt.Sprintf("peer-%d-%d", w, i), State: serial.State_ALIVE},
+					Expiry:    time.Now().Add(swim.PeerTTL),
+					SeenPeers: make(map[string]bool),
+				})
+				queue.GetEntries(fmt.Sprintf("reader-%d", i%3), 5)
+				queue.EvictEntry()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(queue.Entries) > 10 {
+		t.Fatalf("Expected queue to stay within capacity, got %d entries", len(queue.Entries))
+	}
+}
+
+func TestPiggyBackQueueSupersedesOlderUpdates(t *testing.T) {
+	queue := &swim.PiggyBackQueue{
+		Entries:  make([]*swim.Entry, 0),
+		Capacity: 10,
+	}
+	add := func(incarnation uint64, state serial.State) {
+		queue.AddEntry(&swim.Entry{
+			Update:    &serial.MembershipUpdate{NodeId: "test-peer", Incarnation: incarnation, State: state},
+			Expiry:    time.Now().Add(swim.PeerTTL),
+			SeenPeers: make(map[string]bool),
+		})
+	}
+
+	add(1, serial.State_ALIVE)
+	add(1, serial.State_SUSPECT)
+	add(1, serial.State_ALIVE)
+	if len(queue.Entries) != 1 || queue.Entries[0].Update.State != serial.State_SUSPECT {
+		t.Fatalf("Expected a single SUSPECT entry to win at the same incarnation, got %d entries", len(queue.Entries))
+	}
+
+	add(2, serial.State_ALIVE)
+	add(1, serial.State_DEAD)
+	if len(queue.Entries) != 1 || queue.Entries[0].Update.Incarnation != 2 || queue.Entries[0].Update.State != serial.State_ALIVE {
+		t.Fatalf("Expected the refutation at incarnation 2 to be kept, got %v", queue.Entries[0].Update)
+	}
+}
+
+func TestPiggyBackQueuePrioritizesFewestTransmissions(t *testing.T) {
+	queue := &swim.PiggyBackQueue{
+		Entries:  make([]*swim.Entry, 0),
+		Capacity: 10,
+	}
+	for _, id := range []string{"old-news", "fresh-news"} {
+		queue.AddEntry(&swim.Entry{
+			Update:    &serial.MembershipUpdate{NodeId: id, Incarnation: 1, State: serial.State_ALIVE},
+			Expiry:    time.Now().Add(swim.PeerTTL),
+			SeenPeers: make(map[string]bool),
+		})
+		// Send only the first update once so it has been transmitted more
+		if id == "old-news" {
+			queue.GetEntries("peer-a", 1)
+		}
+	}
+
+	entries := queue.GetEntries("peer-b", 1)
+	if len(entries) != 1 || entries[0].Update.NodeId != "fresh-news" {
+		t.Fatalf("Expected the untransmitted update first, got %v", entries)
+	}
+}
+
+func TestPiggyBackQueueRespectsByteBudget(t *testing.T) {
+	queue := &swim.PiggyBackQueue{
+		Entries:  make([]*swim.Entry, 0),
+		Capacity: 100,
+	}
+	for i := 0; i < 50; i++ {
+		queue.AddEntry(&swim.Entry{
+			Update: &serial.MembershipUpdate{
+				NodeId:      fmt.Sprintf("node-%02d", i),
+				Address:     "127.0.0.1:8080",
+				Incarnation: 1,
+				State:       serial.State_ALIVE,
+			},
+			Expiry:    time.Now().Add(swim.PeerTTL),
+			SeenPeers: make(map[string]bool),
+		})
+	}
+
+	const budget = 300
+	ping := &serial.Ping{}
+	for _, entry := range queue.GetEntriesWithin("peer", budget) {
+		ping.Updates = append(ping.Updates, entry.Update)
+	}
+	if len(ping.Updates) == 0 {
+		t.Fatal("Expected some updates to fit the budget")
+	}
+	if size := proto.Size(ping); size > budget {
+		t.Fatalf("Expected piggybacked updates to fit in %d bytes, got %d", budget, size)
+	}
+}
+
+func TestMerkleTreeSubtreeDiffDescendsOnlyIntoChangedSubtrees(t *testing.T) {
+	clk := clock.NewFake(time.Unix(100, 0))
+	local := sync.NewMerkleTree("local", 16)
+	remote := sync.NewMerkleTree("remote", 16)
+	local.Clock = clk
+	remote.Clock = clk
+
+	const keys = 1024
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("key-%04d", i)
+		local.AddData(key, []byte("value"))
+		remote.AddData(key, []byte("value"))
+	}
+	clk.Advance(time.Second)
+	remote.UpdateData("key-0517", []byte("changed"))
+
+	// Walk the remote tree level by level the way the sync service does
+	root, _ := remote.GetSubtree(nil)
+	subtrees := []sync.SubtreeHash{root}
+	var parents [][]int
+	var push []sync.DataItem
+	var want []string
+	exchanged := len(subtrees)
+	for {
+		items, keys, expand := local.CompareSubtrees("remote", subtrees, parents)
+		push = append(push, items...)
+		want = append(want, keys...)
+		if len(expand) == 0 {
+			break
+		}
+		subtrees = remote.GetChildHashes(expand)
+		parents = expand
+		exchanged += len(subtrees)
+	}
+
+	// The differing key is swapped both ways so each side can resolve the conflict
+	if len(push) != 1 || push[0].Key != "key-0517" {
+		t.Fatalf("Expected to push only key-0517, got %v", push)
+	}
+	if len(want) != 1 || want[0] != "key-0517" {
+		t.Fatalf("Expected to want only key-0517, got %v", want)
+	}
+	// A handful of children per level on the changed path, not every leaf
+	if exchanged > 64 {
+		t.Fatalf("Expected O(log n) subtree hashes, exchanged %d", exchanged)
+	}
+
+	local.MergeDiff(remote.GetItems(want))
+	if local.GetTreeHash() != remote.GetTreeHash() {
+		t.Fatal("Expected trees to match after merging the wanted keys")
+	}
+}
+
+func TestMerkleTreeBatchWriteMatchesSingleWrites(t *testing.T) {
+	clk := clock.NewFake(time.Unix(100, 0))
+	single := sync.NewMerkleTree("node-1", 4)
+	batched := sync.NewMerkleTree("node-1", 4)
+	single.Clock = clk
+	batched.Clock = clk
+
+	var writes []sync.Write
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("sensor-%03d", i)
+		value := []byte(fmt.Sprintf("reading-%d", i))
+		single.AddData(key, value)
+		writes = append(writes, sync.Write{Key: key, Value: value})
+	}
+	single.DeleteData("sensor-042")
+	writes = append(writes, sync.Write{Key: "sensor-042"})
+
+	if err := batched.WriteBatch(writes); err != nil {
+		t.Fatalf("Failed to write batch: %v", err)
+	}
+	if single.GetTreeHash() != batched.GetTreeHash() {
+		t.Fatal("Expected a batch to hash the same as the equivalent single writes")
+	}
+	if _, err := batched.GetData("sensor-042"); err == nil {
+		t.Fatal("Expected deleted key to be absent after the batch")
+	}
+
+	clk.Advance(sync.DefaultTombstoneTTL)
+	batched.CollectTombstones(nil)
+	before := batched.GetTreeHash()
+	batched.AddData("extra", []byte("value"))
+	batched.DeleteData("extra")
+	clk.Advance(sync.DefaultTombstoneTTL)
+	batched.CollectTombstones(nil)
+	if batched.GetTreeHash() != before {
+		t.Fatal("Expected adding, deleting and collecting a key to restore the previous hash")
+	}
+}
+
+func TestMerkleTreeLayoutIsComparableAcrossTrees(t *testing.T) {
+	clk := clock.NewFake(time.Unix(100, 0))
+	forward := sync.NewMerkleTree("node-1", 4)
+	backward := sync.NewMerkleTree("node-2", 4)
+	forward.Clock = clk
+	backward.Clock = clk
+
+	const keys = 500
+	for i := 0; i < keys; i++ {
+		forward.AddData(fmt.Sprintf("key-%d", i), []byte("value"))
+		backward.AddData(fmt.Sprintf("key-%d", keys-1-i), []byte("value"))
+	}
+	if forward.GetTreeHash() != backward.GetTreeHash() {
+		t.Fatal("Expected insertion order not to change the tree hash")
+	}
+
+	// One extra key should change only the root children on its path
+	backward.AddData("extra", []byte("value"))
+	before := forward.GetChildHashes([][]int{nil})
+	after := backward.GetChildHashes([][]int{nil})
+	if len(before) != len(after) {
+		t.Fatalf("Expected the same root children, got %d and %d", len(before), len(after))
+	}
+	changed := 0
+	for i := range before {
+		if before[i].Hash != after[i].Hash {
+			changed++
+		}
+	}
+	if changed != 1 {
+		t.Fatalf("Expected exactly one root child to change, got %d", changed)
+	}
+}
+
+func TestMerkleTreeConcurrentWritesResolveLastWriterWins(t *testing.T) {
+	clk := clock.NewFake(time.Unix(100, 0))
+	east := sync.NewMerkleTree("gateway-east", 4)
+	west := sync.NewMerkleTree("gateway-west", 4)
+	east.Clock = clk
+	west.Clock = clk
+
+	// Same wall time on both gateways, so the writer ID breaks the tie
+	east.AddData("config", []byte("east"))
+	west.AddData("config", []byte("west"))
+
+	eastItems := east.GetDiff("", nil)
+	westItems := west.GetDiff("", nil)
+	east.ApplyDiff(westItems)
+	west.ApplyDiff(eastItems)
+
+	for _, tree := range []*sync.MerkleTree{east, west} {
+		value, _ := tree.GetData("config")
+		if string(value) != "west" {
+			t.Fatalf("Expected the higher node ID to win the tie, got %q", value)
+		}
+		siblings := tree.GetSiblings("config")
+		if len(siblings) != 1 || siblings[0].Dot != (sync.Dot{Node: "gateway-west", Counter: 1}) {
+			t.Fatalf("Expected only the winning write to be kept, got %+v", siblings)
+		}
+	}
+	if east.GetTreeHash() != west.GetTreeHash() {
+		t.Fatal("Expected both gateways to converge on the same hash")
+	}
+
+	// A later write on the losing side has seen both, so it supersedes the resolved value
+	clk.Advance(time.Second)
+	east.UpdateData("config", []byte("east-again"))
+	west.ApplyDiff(east.GetDiff("", nil))
+	if value, _ := west.GetData("config"); string(value) != "east-again" {
+		t.Fatalf("Expected the later write to win, got %q", value)
+	}
+	if dot := west.GetSiblings("config")[0].Dot; dot != (sync.Dot{Node: "gateway-east", Counter: 2}) {
+		t.Fatalf("Expected the update to carry east's second dot, got %+v", dot)
+	}
+}
+
+func TestMerkleTreeUsesCustomConflictResolver(t *testing.T) {
+	tree := sync.NewMerkleTree("local", 4)
+	tree.Resolver = func(local, remote sync.DataItem) sync.DataItem {
+		local.Value = append(append([]byte{}, local.Value...), remote.Value...)
+		return local
+	}
+
+	tree.AddData("log", []byte("a"))
+	peer := sync.NewMerkleTree("peer", 4)
+	peer.ApplyDiff(tree.GetDiff("", nil))
+	tree.ApplyDiff([]sync.DataItem{{Key: "log", Value: []byte("b"), Dot: sync.Dot{Node: "remote", Counter: 1}}})
+
+	if value, _ := tree.GetData("log"); string(value) != "ab" {
+		t.Fatalf("Expected the resolver's merged value, got %q", value)
+	}
+	if dot := tree.GetSiblings("log")[0].Dot; dot != (sync.Dot{Node: "local", Counter: 2}) {
+		t.Fatalf("Expected the merged value to be a new local write, got %+v", dot)
+	}
+
+	// A peer holding the local write the merge started from takes the merge as newer
+	peer.ApplyDiff(tree.GetDiff("", nil))
+	if value, _ := peer.GetData("log"); string(value) != "ab" {
+		t.Fatalf("Expected the peer to pick up the merged value, got %q", value)
+	}
+}
+
+func TestMerkleTreeTombstonesStopResurrectionAndExpire(t *testing.T) {
+	clk := clock.NewFake(time.Unix(100, 0))
+	local := sync.NewMerkleTree("local", 4)
+	remote := sync.NewMerkleTree("remote", 4)
+	local.Clock = clk
+	remote.Clock = clk
+	local.TombstoneTTL = time.Hour
+
+	local.AddData("key1", []byte("value1"))
+	remote.ApplyDiff(local.GetDiff(remote.GetTreeHash(), remote.GetLeaves()))
+
+	clk.Advance(time.Second)
+	if err := local.DeleteData("key1"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if _, err := local.GetData("key1"); err == nil {
+		t.Fatal("Expected deleted key to be absent")
+	}
+
+	// The stale copy on the remote must not bring the key back
+	local.ApplyDiff(remote.GetDiff(local.GetTreeHash(), local.GetLeaves()))
+	if _, err := local.GetData("key1"); err == nil {
+		t.Fatal("Expected the tombstone to beat the older remote copy")
+	}
+	remote.ApplyDiff(local.GetDiff(remote.GetTreeHash(), remote.GetLeaves()))
+	if _, err := remote.GetData("key1"); err == nil {
+		t.Fatal("Expected the delete to reach the remote")
+	}
+	if local.GetTreeHash() != remote.GetTreeHash() {
+		t.Fatal("Expected trees with the same tombstone to hash the same")
+	}
+
+	if removed := local.CollectTombstones([]string{"remote"}); removed != 0 {
+		t.Fatalf("Expected an unacknowledged tombstone to be kept, removed %d", removed)
+	}
+	if removed := local.CollectTombstones(nil); removed != 0 {
+		t.Fatalf("Expected no members to acknowledge nothing, removed %d", removed)
+	}
+	clk.Advance(time.Hour)
+	if removed := local.CollectTombstones([]string{"remote"}); removed != 1 {
+		t.Fatalf("Expected the tombstone to be collected after the grace period, removed %d", removed)
+	}
+	if len(local.GetLeaves()) != 0 {
+		t.Fatalf("Expected no leaves after collection, got %d", len(local.GetLeaves()))
+	}
+}
+
+func TestMerkleTreeKeepsConcurrentWritesAsSiblings(t *testing.T) {
+	clk := clock.NewFake(time.Unix(100, 0))
+	east := sync.NewMerkleTree("gateway-east", 4)
+	west := sync.NewMerkleTree("gateway-west", 4)
+	east.Clock, east.KeepSiblings = clk, true
+	west.Clock, west.KeepSiblings = clk, true
+
+	// A write that has seen the other side's write replaces it instead of conflicting
+	east.AddData("config", []byte("v1"))
+	west.ApplyDiff(east.GetDiff("", nil))
+	west.UpdateData("config", []byte("v2"))
+	east.ApplyDiff(west.GetDiff("", nil))
+	if siblings := east.GetSiblings("config"); len(siblings) != 1 || string(siblings[0].Value) != "v2" {
+		t.Fatalf("Expected the causally newer write to replace the old one, got %+v", siblings)
+	}
+
+	// Writes made during a partition are concurrent and both kept
+	clk.Advance(time.Second)
+	east.UpdateData("config", []byte("east"))
+	west.UpdateData("config", []byte("west"))
+	eastItems := east.GetDiff("", nil)
+	east.ApplyDiff(west.GetDiff("", nil))
+	west.ApplyDiff(eastItems)
+
+	for _, tree := range []*sync.MerkleTree{east, west} {
+		siblings := tree.GetSiblings("config")
+		if len(siblings) != 2 {
+			t.Fatalf("Expected two concurrent siblings, got %+v", siblings)
+		}
+	}
+	if east.GetTreeHash() != west.GetTreeHash() {
+		t.Fatal("Expected both gateways to hash the same siblings the same")
+	}
+
+	// Writing the key after reading the siblings resolves the conflict everywhere
+	east.UpdateData("config", []byte("merged"))
+	west.ApplyDiff(east.GetDiff("", nil))
+	for _, tree := range []*sync.MerkleTree{east, west} {
+		siblings := tree.GetSiblings("config")
+		if len(siblings) != 1 || string(siblings[0].Value) != "merged" {
+			t.Fatalf("Expected the resolving write to replace both siblings, got %+v", siblings)
+		}
+	}
+}
+
+func TestMerkleTreeRewriteAfterTombstoneCollected(t *testing.T) {
+	clk := clock.NewFake(time.Unix(100, 0))
+	local := sync.NewMerkleTree("local", 4)
+	remote := sync.NewMerkleTree("remote", 4)
+	local.Clock = clk
+	remote.Clock = clk
+
+	local.AddData("key1", []byte("old"))
+	remote.ApplyDiff(local.GetDiff("", nil))
+
+	// The remote misses both the delete and the collection of its tombstone
+	clk.Advance(time.Second)
+	local.DeleteData("key1")
+	clk.Advance(sync.DefaultTombstoneTTL)
+	local.CollectTombstones(nil)
+
+	clk.Advance(time.Second)
+	local.AddData("key1", []byte("new"))
+	if dot := local.GetSiblings("key1")[0].Dot; dot.Counter <= 2 {
+		t.Fatalf("Expected the rewrite to get a dot not used before the collection, got %+v", dot)
+	}
+
+	localItems := local.GetDiff("", nil)
+	local.ApplyDiff(remote.GetDiff("", nil))
+	remote.ApplyDiff(localItems)
+	for _, tree := range []*sync.MerkleTree{local, remote} {
+		if value, _ := tree.GetData("key1"); string(value) != "new" {
+			t.Fatalf("Expected the rewrite to win, got %q", value)
+		}
+	}
+	if local.GetTreeHash() != remote.GetTreeHash() {
+		t.Fatal("Expected both trees to converge after the rewrite")
+	}
+}
+
+func TestMerkleTreeGrownContextDropsCoveredSiblings(t *testing.T) {
+	tree := sync.NewMerkleTree("local", 4)
+	tree.KeepSiblings = true
+
+	east := sync.Dot{Node: "gateway-east", Counter: 1}
+	west := sync.Dot{Node: "gateway-west", Counter: 1}
+	tree.ApplyDiff([]sync.DataItem{
+		{Key: "config", Value: []byte("west"), Dot: west},
+		{Key: "config", Value: []byte("east"), Dot: east},
+	})
+	if siblings := tree.GetSiblings("config"); len(siblings) != 2 {
+		t.Fatalf("Expected two concurrent siblings, got %+v", siblings)
+	}
+
+	// A peer that resolved the conflict in favor of east sends the same write with a context covering west
+	tree.ApplyDiff([]sync.DataItem{{
+		Key:     "config",
+		Value:   []byte("east"),
+		Dot:     east,
+		Context: sync.VersionVector{"gateway-east": 1, "gateway-west": 1},
+	}})
+	siblings := tree.GetSiblings("config")
+	if len(siblings) != 1 || siblings[0].Dot != east {
+		t.Fatalf("Expected the grown context to drop the covered sibling, got %+v", siblings)
+	}
+}
+
+func TestMerkleTreeEmptyValueIsNotADelete(t *testing.T) {
+	local := sync.NewMerkleTree("local", 4)
+	remote := sync.NewMerkleTree("remote", 4)
+
+	local.AddData("flag", nil)
+	local.AddData("note", []byte{})
+	remote.ApplyDiff(local.GetDiff("", nil))
+
+	for _, key := range []string{"flag", "note"} {
+		if value, err := remote.GetData(key); err != nil || len(value) != 0 {
+			t.Fatalf("Expected %s to sync as a live empty value, got %q, %v", key, value, err)
+		}
+	}
+	if local.GetTreeHash() != remote.GetTreeHash() {
+		t.Fatal("Expected both trees to hash the same")
+	}
+}
@@ -0,0 +1,591 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/jscottransom/fringe/internal/sim"
+	"github.com/jscottransom/fringe/internal/swim"
+	"github.com/jscottransom/fringe/internal/sync"
+)
+
+// Returns a fast protocol configuration for simulated clusters
+func simConfig() swim.Config {
+	config := swim.DefaultConfig()
+	config.ProbeInterval = time.Second
+	config.ProbeTimeout = 200 * time.Millisecond
+	config.PushPullInterval = 10 * time.Second
+	return config
+}
+
+// Silences protocol logging for the rest of the test
+func quietLogs(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+func TestSimClusterConverges(t *testing.T) {
+	quietLogs(t)
+
+	cluster := sim.NewCluster(30, simConfig(), 1)
+	defer cluster.Close()
+	cluster.Network.Default = sim.Link{Latency: 5 * time.Millisecond}
+
+	if err := cluster.Join(); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+
+	elapsed, ok := cluster.RunUntil(60*time.Second, cluster.Converged)
+	if !ok {
+		t.Fatalf("Cluster did not converge within %v", elapsed)
+	}
+	t.Logf("Cluster of 30 converged after %v of virtual time", elapsed)
+}
+
+func TestSimDetectsCrashedNode(t *testing.T) {
+	quietLogs(t)
+
+	config := simConfig()
+	cluster := sim.NewCluster(20, config, 2)
+	defer cluster.Close()
+
+	if err := cluster.Join(); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+	if _, ok := cluster.RunUntil(60*time.Second, cluster.Converged); !ok {
+		t.Fatal("Cluster did not converge before the crash")
+	}
+
+	victim := cluster.Nodes[7]
+	cluster.Kill(7)
+
+	// Members that probe the victim after the first suspicion confirm it, shrinking the timer toward
+	// its minimum of SuspicionMult periods scaled by log10 of the cluster size; the rest of the bound
+	// covers the first probe of the victim and the spread of its death
+	bound := time.Duration(3*config.SuspicionMult) * config.ProbeInterval
+	elapsed, ok := cluster.RunUntil(bound, func() bool {
+		return cluster.AllSee(victim, swim.Dead)
+	})
+	if !ok {
+		t.Fatalf("Crashed node was not declared dead by all members within %v", bound)
+	}
+	t.Logf("Crash detected by all members after %v of virtual time", elapsed)
+
+	for _, node := range cluster.Live() {
+		for _, other := range cluster.Live() {
+			if peer, _ := node.MemberTable.GetPeer(other.NodeId); peer.State == swim.Dead {
+				t.Fatalf("%s falsely declared %s dead", node.NodeId, other.NodeId)
+			}
+		}
+	}
+}
+
+// Crashes one node of a lossy seeded cluster and returns how long detection took
+func simCrashDetectionTime(t *testing.T, seed int64) time.Duration {
+	t.Helper()
+
+	cluster := sim.NewCluster(12, simConfig(), seed)
+	defer cluster.Close()
+
+	if err := cluster.Join(); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+	if _, ok := cluster.RunUntil(60*time.Second, cluster.Converged); !ok {
+		t.Fatal("Cluster did not converge before the crash")
+	}
+	cluster.Network.Default = sim.Link{Latency: 5 * time.Millisecond, Loss: 0.05}
+
+	victim := cluster.Nodes[5]
+	cluster.Kill(5)
+	elapsed, ok := cluster.RunUntil(120*time.Second, func() bool {
+		return cluster.AllSee(victim, swim.Dead)
+	})
+	if !ok {
+		t.Fatal("Crashed node was not declared dead by all members")
+	}
+	return elapsed
+}
+
+func TestSimSameSeedIsReproducible(t *testing.T) {
+	quietLogs(t)
+
+	// Probe order, helper choice and packet loss all come from the seed
+	first := simCrashDetectionTime(t, 4)
+	for run := 0; run < 2; run++ {
+		if elapsed := simCrashDetectionTime(t, 4); elapsed != first {
+			t.Fatalf("Expected the same seed to detect the crash after %v, got %v", first, elapsed)
+		}
+	}
+}
+
+func TestSimNoFalsePositivesUnderPacketLoss(t *testing.T) {
+	quietLogs(t)
+
+	cluster := sim.NewCluster(20, simConfig(), 3)
+	defer cluster.Close()
+
+	if err := cluster.Join(); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+	if _, ok := cluster.RunUntil(60*time.Second, cluster.Converged); !ok {
+		t.Fatal("Cluster did not converge before enabling loss")
+	}
+
+	cluster.Network.Default = sim.Link{Latency: 10 * time.Millisecond, Loss: 0.05}
+	cluster.Run(120 * time.Second)
+
+	for _, node := range cluster.Nodes {
+		for _, other := range cluster.Nodes {
+			if peer, _ := node.MemberTable.GetPeer(other.NodeId); peer.State == swim.Dead {
+				t.Fatalf("%s falsely declared %s dead under 5%% loss", node.NodeId, other.NodeId)
+			}
+		}
+	}
+}
+
+func TestSimPartitionedSidesDeclareEachOtherDead(t *testing.T) {
+	quietLogs(t)
+
+	cluster := sim.NewCluster(10, simConfig(), 4)
+	defer cluster.Close()
+
+	if err := cluster.Join(); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+	if _, ok := cluster.RunUntil(60*time.Second, cluster.Converged); !ok {
+		t.Fatal("Cluster did not converge before the partition")
+	}
+
+	var left, right []string
+	for i, node := range cluster.Nodes {
+		if i < 5 {
+			left = append(left, node.Addr)
+		} else {
+			right = append(right, node.Addr)
+		}
+	}
+	cluster.Network.Partition(left, right)
+
+	split := func() bool {
+		for i, node := range cluster.Nodes {
+			for j, other := range cluster.Nodes {
+				peer, _ := node.MemberTable.GetPeer(other.NodeId)
+				sameSide := (i < 5) == (j < 5)
+				if sameSide && peer.State != swim.Alive {
+					return false
+				}
+				if !sameSide && peer.State != swim.Dead {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if elapsed, ok := cluster.RunUntil(5*time.Minute, split); !ok {
+		t.Fatalf("Partitioned sides did not declare each other dead within %v", elapsed)
+	}
+
+	// Push-pull keeps trying members marked dead, so the sides find each other once the network heals
+	cluster.Network.Heal()
+	elapsed, ok := cluster.RunUntil(5*time.Minute, cluster.Converged)
+	if !ok {
+		t.Fatalf("Cluster did not heal within %v of the partition ending", elapsed)
+	}
+	t.Logf("Cluster healed after %v of virtual time", elapsed)
+}
+
+func TestSimMemberEventsReportJoinSuspectAndDeath(t *testing.T) {
+	quietLogs(t)
+
+	cluster := sim.NewCluster(5, simConfig(), 5)
+	defer cluster.Close()
+
+	observer := cluster.Nodes[0]
+	events, unsubscribe := observer.Subscribe(256)
+	defer unsubscribe()
+
+	if err := cluster.Join(); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+	if _, ok := cluster.RunUntil(60*time.Second, cluster.Converged); !ok {
+		t.Fatal("Cluster did not converge")
+	}
+
+	victim := cluster.Nodes[3]
+	cluster.Kill(3)
+	if _, ok := cluster.RunUntil(2*time.Minute, func() bool { return cluster.AllSee(victim, swim.Dead) }); !ok {
+		t.Fatal("Crashed node was not declared dead")
+	}
+
+	joined := map[string]bool{}
+	var victimEvents []swim.EventType
+	for len(events) > 0 {
+		event := <-events
+		if event.Type == swim.EventJoin {
+			joined[event.Peer.PeerID] = true
+		}
+		if event.Peer.PeerID == victim.NodeId && event.Type != swim.EventJoin {
+			victimEvents = append(victimEvents, event.Type)
+			if event.Type == swim.EventDead && event.OldState != swim.Suspected {
+				t.Fatalf("Expected death to follow suspicion, got old state %v", event.OldState)
+			}
+		}
+	}
+
+	for _, node := range cluster.Nodes[1:] {
+		if !joined[node.NodeId] {
+			t.Fatalf("Expected a join event for %s", node.NodeId)
+		}
+	}
+	if len(victimEvents) == 0 || victimEvents[len(victimEvents)-1] != swim.EventDead {
+		t.Fatalf("Expected the victim's events to end in death, got %v", victimEvents)
+	}
+}
+
+func TestSimMetadataSpreadsAndFiltersByTag(t *testing.T) {
+	quietLogs(t)
+
+	cluster := sim.NewCluster(10, simConfig(), 6)
+	defer cluster.Close()
+
+	if err := cluster.Join(); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+	if _, ok := cluster.RunUntil(60*time.Second, cluster.Converged); !ok {
+		t.Fatal("Cluster did not converge")
+	}
+
+	for _, i := range []int{2, 5} {
+		if err := cluster.Nodes[i].SetMeta(map[string]string{"region": "eu-west", "role": "edge"}); err != nil {
+			t.Fatalf("Failed to set metadata: %v", err)
+		}
+	}
+
+	spread := func() bool {
+		for _, node := range cluster.Nodes {
+			if len(node.MemberTable.GetPeersByTag("region", "eu-west")) != 2 {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := cluster.RunUntil(60*time.Second, spread); !ok {
+		t.Fatal("Metadata did not reach every node")
+	}
+
+	peer, _ := cluster.Nodes[0].MemberTable.GetPeer(cluster.Nodes[2].NodeId)
+	if peer.Incarnation < 2 || peer.Meta["role"] != "edge" {
+		t.Fatalf("Expected tagged peer at a new incarnation, got incarnation %d with meta %v", peer.Incarnation, peer.Meta)
+	}
+}
+
+func TestSimUserBroadcastDeliveredOncePerNode(t *testing.T) {
+	quietLogs(t)
+
+	cluster := sim.NewCluster(20, simConfig(), 7)
+	defer cluster.Close()
+
+	var mu gosync.Mutex
+	received := map[string][]swim.UserMessage{}
+	for _, node := range cluster.Nodes {
+		node.HandleBroadcasts(func(msg swim.UserMessage) {
+			mu.Lock()
+			defer mu.Unlock()
+			received[node.NodeId] = append(received[node.NodeId], msg)
+		})
+	}
+
+	if err := cluster.Join(); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+	if _, ok := cluster.RunUntil(60*time.Second, cluster.Converged); !ok {
+		t.Fatal("Cluster did not converge")
+	}
+
+	origin := cluster.Nodes[4]
+	if err := origin.Broadcast("cache-invalidate", 1, []byte("users/*")); err != nil {
+		t.Fatalf("Failed to broadcast: %v", err)
+	}
+	if err := origin.Broadcast("cache-invalidate", 1, []byte("again")); err == nil {
+		t.Fatal("Expected rebroadcasting the same version to fail")
+	}
+
+	everyone := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, node := range cluster.Nodes {
+			if node != origin && len(received[node.NodeId]) == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := cluster.RunUntil(60*time.Second, everyone); !ok {
+		t.Fatal("Broadcast did not reach every node")
+	}
+
+	// Let the message keep circulating to make sure nobody sees it twice
+	cluster.Run(30 * time.Second)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received[origin.NodeId]) != 0 {
+		t.Fatalf("Expected origin not to receive its own broadcast")
+	}
+	for _, node := range cluster.Nodes {
+		if node == origin {
+			continue
+		}
+		msgs := received[node.NodeId]
+		if len(msgs) != 1 {
+			t.Fatalf("Expected %s to receive the broadcast once, got %d", node.NodeId, len(msgs))
+		}
+		if msgs[0].Name != "cache-invalidate" || string(msgs[0].Payload) != "users/*" || msgs[0].Origin != origin.NodeId {
+			t.Fatalf("Unexpected message delivered to %s: %+v", node.NodeId, msgs[0])
+		}
+	}
+}
+
+func TestSimAntiEntropyConvergesTrees(t *testing.T) {
+	quietLogs(t)
+
+	cluster := sim.NewCluster(5, simConfig(), 11)
+	defer cluster.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	trees := make([]*sync.MerkleTree, len(cluster.Nodes))
+	for i, node := range cluster.Nodes {
+		trees[i] = sync.NewMerkleTree(node.NodeId, 4)
+		trees[i].Clock = cluster.Clock
+		if err := trees[i].AddData(fmt.Sprintf("key-%d", i), []byte(node.NodeId)); err != nil {
+			t.Fatalf("Failed to add data: %v", err)
+		}
+		if err := trees[i].AddData("shared", []byte(node.NodeId)); err != nil {
+			t.Fatalf("Failed to add data: %v", err)
+		}
+		service := sync.NewService(node, trees[i], 5*time.Second)
+		service.Clock = cluster.Clock
+		service.Rand = rand.New(rand.NewSource(int64(i)))
+		service.Start(ctx)
+	}
+
+	if err := cluster.Join(); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+
+	converged := func() bool {
+		hash := trees[0].GetTreeHash()
+		for _, tree := range trees {
+			if tree.GetTreeHash() != hash || len(tree.GetLeaves()) != len(trees)+1 {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := cluster.RunUntil(120*time.Second, converged); !ok {
+		t.Fatal("Trees did not converge")
+	}
+
+	// A write after convergence should reach every node too
+	cluster.Clock.Advance(time.Second)
+	if err := trees[2].UpdateData("shared", []byte("updated")); err != nil {
+		t.Fatalf("Failed to update data: %v", err)
+	}
+	updated := func() bool {
+		for _, tree := range trees {
+			value, err := tree.GetData("shared")
+			if err != nil || string(value) != "updated" {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := cluster.RunUntil(120*time.Second, updated); !ok {
+		t.Fatal("Update did not reach every node")
+	}
+}
+
+func TestSimSyncRejectsMismatchedTreeDepth(t *testing.T) {
+	quietLogs(t)
+
+	cluster := sim.NewCluster(2, simConfig(), 13)
+	defer cluster.Close()
+
+	shallow := sync.NewMerkleTree(cluster.Nodes[0].NodeId, 3)
+	deep := sync.NewMerkleTree(cluster.Nodes[1].NodeId, 4)
+	shallow.AddData("key", []byte("value"))
+	service := sync.NewService(cluster.Nodes[0], shallow, time.Minute)
+	sync.NewService(cluster.Nodes[1], deep, time.Minute)
+
+	if err := service.SyncWith(context.Background(), cluster.Nodes[1].Addr); err == nil {
+		t.Fatal("Expected sync between trees of different depths to fail")
+	}
+	if len(deep.GetLeaves()) != 0 {
+		t.Fatal("Expected nothing to be applied to a tree with a different layout")
+	}
+}
+
+func TestSimSyncPushesEverySibling(t *testing.T) {
+	quietLogs(t)
+
+	cluster := sim.NewCluster(2, simConfig(), 19)
+	defer cluster.Close()
+
+	local := sync.NewMerkleTree(cluster.Nodes[0].NodeId, 4)
+	remote := sync.NewMerkleTree(cluster.Nodes[1].NodeId, 4)
+	local.KeepSiblings, remote.KeepSiblings = true, true
+	service := sync.NewService(cluster.Nodes[0], local, time.Minute)
+	sync.NewService(cluster.Nodes[1], remote, time.Minute)
+
+	// A write from a third node that has not seen the local one is kept next to it
+	local.AddData("config", []byte("local"))
+	local.ApplyDiff([]sync.DataItem{{Key: "config", Value: []byte("other"), Dot: sync.Dot{Node: "node-other", Counter: 1}}})
+	if siblings := local.GetSiblings("config"); len(siblings) != 2 {
+		t.Fatalf("Expected two siblings before syncing, got %+v", siblings)
+	}
+
+	if err := service.SyncWith(context.Background(), cluster.Nodes[1].Addr); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	if siblings := remote.GetSiblings("config"); len(siblings) != 2 {
+		t.Fatalf("Expected one sync to carry both siblings, got %+v", siblings)
+	}
+	if local.GetTreeHash() != remote.GetTreeHash() {
+		t.Fatal("Expected both trees to hash the same after one sync")
+	}
+}
+
+func TestSimDeletesSpreadAndTombstonesAreCollected(t *testing.T) {
+	quietLogs(t)
+
+	cluster := sim.NewCluster(4, simConfig(), 17)
+	defer cluster.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	trees := make([]*sync.MerkleTree, len(cluster.Nodes))
+	for i, node := range cluster.Nodes {
+		trees[i] = sync.NewMerkleTree(node.NodeId, 4)
+		trees[i].Clock = cluster.Clock
+		service := sync.NewService(node, trees[i], 5*time.Second)
+		service.Clock = cluster.Clock
+		service.Rand = rand.New(rand.NewSource(int64(i)))
+		service.Start(ctx)
+	}
+	trees[0].AddData("doomed", []byte("value"))
+
+	if err := cluster.Join(); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+	everywhere := func() bool {
+		for _, tree := range trees {
+			if _, err := tree.GetData("doomed"); err != nil {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := cluster.RunUntil(120*time.Second, everywhere); !ok {
+		t.Fatal("Key did not reach every node")
+	}
+
+	cluster.Clock.Advance(time.Second)
+	if err := trees[3].DeleteData("doomed"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	collected := func() bool {
+		for _, tree := range trees {
+			if len(tree.GetLeaves()) != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := cluster.RunUntil(10*time.Minute, collected); !ok {
+		t.Fatal("Tombstones were not collected once every member had them")
+	}
+	for _, tree := range trees {
+		if _, err := tree.GetData("doomed"); err == nil {
+			t.Fatal("Expected the deleted key to stay deleted")
+		}
+	}
+}
+
+func TestSimDeleteOnIsolatedNodeSurvivesRejoin(t *testing.T) {
+	quietLogs(t)
+
+	cluster := sim.NewCluster(4, simConfig(), 23)
+	defer cluster.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	trees := make([]*sync.MerkleTree, len(cluster.Nodes))
+	for i, node := range cluster.Nodes {
+		trees[i] = sync.NewMerkleTree(node.NodeId, 4)
+		trees[i].Clock = cluster.Clock
+		service := sync.NewService(node, trees[i], 5*time.Second)
+		service.Clock = cluster.Clock
+		service.Rand = rand.New(rand.NewSource(int64(i)))
+		service.Start(ctx)
+	}
+	trees[0].AddData("doomed", []byte("value"))
+
+	if err := cluster.Join(); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+	everywhere := func() bool {
+		for _, tree := range trees {
+			if _, err := tree.GetData("doomed"); err != nil {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := cluster.RunUntil(120*time.Second, everywhere); !ok {
+		t.Fatal("Key did not reach every node")
+	}
+
+	// The isolated node sees no live peers, which must not count as everyone having its tombstone
+	isolated := cluster.Nodes[3]
+	var rest []string
+	for _, node := range cluster.Nodes[:3] {
+		rest = append(rest, node.Addr)
+	}
+	cluster.Network.Partition([]string{isolated.Addr}, rest)
+	cluster.Clock.Advance(time.Second)
+	if err := trees[3].DeleteData("doomed"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	cluster.Run(3 * time.Minute)
+	if len(trees[3].GetLeaves()) != 1 {
+		t.Fatal("Expected the isolated node to keep its tombstone")
+	}
+
+	cluster.Network.Heal()
+	deleted := func() bool {
+		for _, tree := range trees {
+			if _, err := tree.GetData("doomed"); err == nil {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := cluster.RunUntil(5*time.Minute, func() bool { return cluster.Converged() && deleted() }); !ok {
+		t.Fatal("Expected the delete to reach every node after the partition healed")
+	}
+	cluster.Run(5 * time.Minute)
+	if !deleted() {
+		t.Fatal("Expected the deleted key to stay deleted")
+	}
+}
@@ -0,0 +1,408 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	serial "github.com/jscottransom/fringe/internal/proto"
+	"github.com/jscottransom/fringe/internal/swim"
+)
+
+// Starts a node serving QUIC on a random loopback port
+func startTestNode(t *testing.T, ctx context.Context) *swim.Node {
+	t.Helper()
+
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+	if err != nil {
+		t.Fatalf("Failed to listen UDP: %v", err)
+	}
+	t.Cleanup(func() { udp.Close() })
+
+	tr, err := swim.NewQUICTransport(udp)
+	if err != nil {
+		t.Fatalf("Failed to create QUIC transport: %v", err)
+	}
+	return serveTestNode(ctx, udp.LocalAddr().String(), tr)
+}
+
+// Builds a node reachable at addr over the given transport and starts serving it
+func serveTestNode(ctx context.Context, addr string, tr swim.Transport) *swim.Node {
+	nodeID := fmt.Sprintf("node-%s", addr)
+
+	memberTable := &swim.NodeTable{
+		Members: make(map[string]*swim.Peer),
+	}
+	memberTable.AddPeer(nodeID, &swim.Peer{
+		PeerID:           nodeID,
+		Address:          addr,
+		State:            swim.Alive,
+		Incarnation:      1,
+		SinceStateUpdate: time.Now(),
+	})
+
+	queue := &swim.PiggyBackQueue{
+		Entries:  make([]*swim.Entry, 0),
+		Capacity: 10,
+		NumNodes: memberTable.GetClusterSize,
+	}
+	queue.AddEntry(&swim.Entry{
+		Update: &serial.MembershipUpdate{
+			NodeId:      nodeID,
+			Address:     addr,
+			Incarnation: 1,
+			State:       serial.State_ALIVE,
+		},
+		Expiry:    time.Now().Add(swim.PeerTTL),
+		SeenPeers: make(map[string]bool),
+	})
+
+	node := &swim.Node{
+		NodeId:      nodeID,
+		MemberTable: memberTable,
+		Queue:       queue,
+		Addr:        addr,
+		Transport:   tr,
+	}
+	go node.Serve(ctx)
+	return node
+}
+
+func TestSWIMPingOverQUIC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	seed := startTestNode(t, ctx)
+	joiner := startTestNode(t, ctx)
+
+	// Joining sends an Envelope-wrapped Ping and expects an Ack in return
+	if err := joiner.JoinCluster(seed.Addr); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+}
+
+func TestSWIMRepeatedPingsReuseConnection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	seed := startTestNode(t, ctx)
+	joiner := startTestNode(t, ctx)
+
+	// Each ping opens a new stream on the same pooled connection
+	for i := 0; i < 5; i++ {
+		if err := joiner.JoinCluster(seed.Addr); err != nil {
+			t.Fatalf("Ping %d failed: %v", i, err)
+		}
+	}
+
+	listener := seed.Transport.(*swim.QUICTransport)
+	if accepted := listener.Accepted(); accepted != 1 {
+		t.Fatalf("Expected 5 pings to share 1 connection, seed accepted %d", accepted)
+	}
+
+	// Forgetting the peer drops the pooled connection so the next ping dials again
+	joiner.Transport.(*swim.QUICTransport).Forget(seed.Addr)
+	if err := joiner.JoinCluster(seed.Addr); err != nil {
+		t.Fatalf("Ping after Forget failed: %v", err)
+	}
+	if accepted := listener.Accepted(); accepted != 2 {
+		t.Fatalf("Expected a new connection after Forget, seed accepted %d", accepted)
+	}
+}
+
+func TestSWIMUnreachablePeerSuspectedAfterIndirectProbes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := startTestNode(t, ctx)
+	helper := startTestNode(t, ctx)
+	node.Config = swim.Config{
+		ProbeInterval:  300 * time.Millisecond,
+		ProbeTimeout:   250 * time.Millisecond,
+		IndirectChecks: 1,
+	}
+
+	// Reserve an address and release it so nothing answers there
+	dead, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+	if err != nil {
+		t.Fatalf("Failed to listen UDP: %v", err)
+	}
+	deadAddr := dead.LocalAddr().String()
+	dead.Close()
+
+	for id, addr := range map[string]string{helper.NodeId: helper.Addr, "dead-peer": deadAddr} {
+		node.MemberTable.AddPeer(id, &swim.Peer{
+			PeerID:           id,
+			Address:          addr,
+			State:            swim.Alive,
+			Incarnation:      1,
+			SinceStateUpdate: time.Now(),
+		})
+	}
+
+	if err := node.StartGossip(ctx); err != nil {
+		t.Fatalf("Failed to start gossip: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		alive := map[string]bool{}
+		for _, peer := range node.MemberTable.GetAlivePeers() {
+			alive[peer.PeerID] = true
+		}
+		if !alive["dead-peer"] {
+			if !alive[helper.NodeId] {
+				t.Fatal("Expected reachable helper to stay alive")
+			}
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("Expected unreachable peer to be marked suspected")
+}
+
+func TestSWIMRefutesSuspicionAboutSelf(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	accused := startTestNode(t, ctx)
+	accuser := startTestNode(t, ctx)
+
+	// Spread a rumor that the accused node is suspected at its current incarnation
+	accuser.Queue.AddEntry(&swim.Entry{
+		Update: &serial.MembershipUpdate{
+			NodeId:      accused.NodeId,
+			Address:     accused.Addr,
+			Incarnation: 1,
+			State:       serial.State_SUSPECT,
+		},
+		Expiry:    time.Now().Add(swim.PeerTTL),
+		SeenPeers: make(map[string]bool),
+	})
+
+	if err := accuser.JoinCluster(accused.Addr); err != nil {
+		t.Fatalf("Failed to ping accused node: %v", err)
+	}
+
+	for _, peer := range accused.MemberTable.GetAlivePeers() {
+		if peer.PeerID == accused.NodeId {
+			if peer.Incarnation != 2 {
+				t.Fatalf("Expected incarnation to be bumped to 2, got %d", peer.Incarnation)
+			}
+			return
+		}
+	}
+	t.Fatal("Expected accused node to remain alive in its own table")
+}
+
+func TestSWIMGracefulLeave(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leaver := startTestNode(t, ctx)
+	peer := startTestNode(t, ctx)
+
+	leaver.MemberTable.AddPeer(peer.NodeId, &swim.Peer{
+		PeerID:           peer.NodeId,
+		Address:          peer.Addr,
+		State:            swim.Alive,
+		Incarnation:      1,
+		SinceStateUpdate: time.Now(),
+	})
+	peer.MemberTable.AddPeer(leaver.NodeId, &swim.Peer{
+		PeerID:           leaver.NodeId,
+		Address:          leaver.Addr,
+		State:            swim.Alive,
+		Incarnation:      1,
+		SinceStateUpdate: time.Now(),
+	})
+
+	leaveCtx, leaveCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer leaveCancel()
+	if err := leaver.Leave(leaveCtx); err != nil {
+		t.Fatalf("Failed to leave: %v", err)
+	}
+
+	if size := peer.MemberTable.GetClusterSize(); size != 1 {
+		t.Fatalf("Expected leaver to be removed from cluster size, got %d", size)
+	}
+	for _, p := range peer.MemberTable.GetAlivePeers() {
+		if p.PeerID == leaver.NodeId {
+			t.Fatal("Expected leaver to no longer be alive")
+		}
+	}
+}
+
+func TestSWIMJoinExchangesFullState(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	seed := startTestNode(t, ctx)
+	joiner := startTestNode(t, ctx)
+
+	// The seed already knows about a member the joiner has never heard of
+	seed.MemberTable.AddPeer("existing-member", &swim.Peer{
+		PeerID:           "existing-member",
+		Address:          "127.0.0.1:9",
+		State:            swim.Alive,
+		Incarnation:      3,
+		SinceStateUpdate: time.Now(),
+	})
+
+	if err := joiner.JoinCluster(seed.Addr); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+
+	known := map[string]bool{}
+	for _, peer := range joiner.MemberTable.GetAlivePeers() {
+		known[peer.PeerID] = true
+	}
+	for _, id := range []string{seed.NodeId, "existing-member"} {
+		if !known[id] {
+			t.Fatalf("Expected joiner to learn %s from push-pull", id)
+		}
+	}
+
+	if size := seed.MemberTable.GetClusterSize(); size != 3 {
+		t.Fatalf("Expected seed to learn the joiner, got cluster size %d", size)
+	}
+}
+
+func TestSWIMMembershipSpreadsBeyondDirectContacts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := startTestNode(t, ctx)
+	b := startTestNode(t, ctx)
+	c := startTestNode(t, ctx)
+
+	// a only ever talks to b, and c only ever talks to b
+	if err := a.JoinCluster(b.Addr); err != nil {
+		t.Fatalf("Failed to join a to b: %v", err)
+	}
+	if err := c.JoinCluster(b.Addr); err != nil {
+		t.Fatalf("Failed to join c to b: %v", err)
+	}
+
+	// a hears about c on its next exchange with b
+	if err := a.JoinCluster(b.Addr); err != nil {
+		t.Fatalf("Failed to ping b: %v", err)
+	}
+
+	for _, peer := range a.MemberTable.GetAlivePeers() {
+		if peer.PeerID == c.NodeId {
+			return
+		}
+	}
+	t.Fatal("Expected a to learn about c through b")
+}
+
+func TestSWIMProbeOrderIncludesJoinedMembers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	prober := startTestNode(t, ctx)
+	members := []*swim.Node{startTestNode(t, ctx), startTestNode(t, ctx), startTestNode(t, ctx)}
+	for _, member := range members {
+		if err := prober.JoinCluster(member.Addr); err != nil {
+			t.Fatalf("Failed to join %s: %v", member.Addr, err)
+		}
+	}
+
+	order, next := prober.ProbeOrder()
+	if next != 0 {
+		t.Fatalf("Expected no probes yet, got next index %d", next)
+	}
+	seen := map[string]int{}
+	for _, id := range order {
+		seen[id]++
+	}
+	if seen[prober.NodeId] != 0 {
+		t.Fatal("Expected the prober not to probe itself")
+	}
+	for _, member := range members {
+		if seen[member.NodeId] != 1 {
+			t.Fatalf("Expected %s exactly once in probe order %v", member.NodeId, order)
+		}
+	}
+}
+
+func TestSWIMJoinOverMemoryTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	network := swim.NewMemoryNetwork()
+	seed := serveTestNode(ctx, "seed", network.NewTransport("seed"))
+	joiner := serveTestNode(ctx, "joiner", network.NewTransport("joiner"))
+
+	if err := joiner.JoinCluster(seed.Addr); err != nil {
+		t.Fatalf("Failed to join cluster: %v", err)
+	}
+	if size := seed.MemberTable.GetClusterSize(); size != 2 {
+		t.Fatalf("Expected seed to learn the joiner, got cluster size %d", size)
+	}
+	if _, ok := joiner.MemberTable.GetPeer(seed.NodeId); !ok {
+		t.Fatalf("Expected joiner to learn the seed")
+	}
+}
+
+func TestSWIMPingOverUDPTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := func() *swim.Node {
+		udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+		if err != nil {
+			t.Fatalf("Failed to listen UDP: %v", err)
+		}
+		t.Cleanup(func() { udp.Close() })
+		return serveTestNode(ctx, udp.LocalAddr().String(), swim.NewUDPTransport(udp))
+	}
+	a, b := start(), start()
+
+	if err := a.JoinCluster(b.Addr); err != nil {
+		t.Fatalf("Failed to join over UDP: %v", err)
+	}
+	if _, ok := b.MemberTable.GetPeer(a.NodeId); !ok {
+		t.Fatalf("Expected %s to learn %s over UDP", b.NodeId, a.NodeId)
+	}
+}
+
+func TestSWIMRefuteEmitsEvent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	network := swim.NewMemoryNetwork()
+	accused := serveTestNode(ctx, "accused", network.NewTransport("accused"))
+	accuser := serveTestNode(ctx, "accuser", network.NewTransport("accuser"))
+
+	events, unsubscribe := accused.Subscribe(16)
+	defer unsubscribe()
+
+	accuser.Queue.AddEntry(&swim.Entry{
+		Update: &serial.MembershipUpdate{
+			NodeId:      accused.NodeId,
+			Address:     accused.Addr,
+			Incarnation: 1,
+			State:       serial.State_SUSPECT,
+		},
+		Expiry:    time.Now().Add(swim.PeerTTL),
+		SeenPeers: make(map[string]bool),
+	})
+	if err := accuser.JoinCluster(accused.Addr); err != nil {
+		t.Fatalf("Failed to ping accused node: %v", err)
+	}
+
+	for len(events) > 0 {
+		event := <-events
+		if event.Type == swim.EventRefute && event.Peer.PeerID == accused.NodeId {
+			if event.Peer.Incarnation != 2 || event.NewState != swim.Alive {
+				t.Fatalf("Expected refutation at incarnation 2, got %d in state %v", event.Peer.Incarnation, event.NewState)
+			}
+			return
+		}
+	}
+	t.Fatal("Expected a refute event about the accused node")
+}